@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sync"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	flagSamplerInterval = flag.Duration("sampler.interval", 0,
+		"Sample temperature, clocks and throttled state in the background at this interval (0 disables)")
+	flagThrottleInterval = flag.Duration("throttle.poll-interval", 0,
+		"Poll and clear the throttled flags at this interval to count throttle events (0 disables)")
+)
+
+// collectFunc collects a snapshot.
+type collectFunc func() (*snapshot.Snapshot, error)
+
+// collectorFlags holds the --collector.<name> and --no-collector.<name> flags of each section.
+var collectorFlags = func() map[string][2]*bool {
+	flags := make(map[string][2]*bool, len(snapshot.Sections))
+
+	for _, section := range snapshot.Sections {
+		flags[section] = [2]*bool{
+			flag.Bool("collector."+section, true, "Enable the "+section+" collector"),
+			flag.Bool("no-collector."+section, false, "Disable the "+section+" collector"),
+		}
+	}
+
+	return flags
+}()
+
+// collectorFilter returns the filter selecting the sections enabled by flags.
+func collectorFilter() snapshot.Filter {
+	filter := make(snapshot.Filter, len(collectorFlags))
+
+	for section, flags := range collectorFlags {
+		if *flags[0] && !*flags[1] {
+			filter[section] = true
+		}
+	}
+
+	return filter
+}
+
+// newCollector starts the background samplers enabled by flags and returns a function that
+// collects a snapshot of the enabled sections including their readings, and a function that stops
+// the samplers and waits until they have closed the mailbox. The samplers also stop when ctx is
+// done.
+func newCollector(ctx context.Context) (collectFunc, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	filter := collectorFilter()
+
+	var (
+		wg      sync.WaitGroup
+		sampler *snapshot.Sampler
+	)
+
+	if *flagSamplerInterval > 0 && filter.Enabled(snapshot.SectionSampler) {
+		sampler = snapshot.NewSampler(*flagSamplerInterval)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := sampler.Run(ctx); err != nil {
+				log.WithError(err).Error("unable to run sampler")
+			}
+		}()
+	}
+
+	var monitor *snapshot.ThrottleMonitor
+
+	if *flagThrottleInterval > 0 && filter.Enabled(snapshot.SectionThrottle) {
+		monitor = snapshot.NewThrottleMonitor(*flagThrottleInterval)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := monitor.Run(ctx); err != nil {
+				log.WithError(err).Error("unable to run throttle monitor")
+			}
+		}()
+	}
+
+	collect := func() (*snapshot.Snapshot, error) {
+		snap, err := snapshot.CollectFiltered(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		if sampler != nil {
+			snap.Samples = sampler.Drain()
+		}
+
+		if monitor != nil {
+			snap.ThrottleEvents = monitor.Events()
+		}
+
+		return snap, nil
+	}
+
+	stop := func() {
+		cancel()
+		wg.Wait()
+	}
+
+	return collect, stop
+}
+
+// newProbeCollector returns a function that collects a snapshot of the enabled sections without
+// the background samplers, so that probing the mailbox does not drain readings meant for scrapes.
+func newProbeCollector() collectFunc {
+	filter := collectorFilter()
+	delete(filter, snapshot.SectionSampler)
+
+	return func() (*snapshot.Snapshot, error) {
+		return snapshot.CollectFiltered(filter)
+	}
+}
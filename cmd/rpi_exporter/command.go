@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// command runs a subcommand with the arguments that follow its name.
+type command func(args []string) error
+
+var commands = map[string]command{
+	"get_throttled": runGetThrottled,
+	"graphite":      runGraphite,
+	"influx":        runInflux,
+	"measure_clock": runMeasureClock,
+	"measure_temp":  runMeasureTemp,
+	"measure_volts": runMeasureVolts,
+	"mqtt":          runMQTT,
+	"otlp":          runOTLP,
+	"push":          runPush,
+	"remote_write":  runRemoteWrite,
+	"snmp":          runSNMP,
+	"snmp_mib":      runSNMPMIB,
+	"statsd":        runStatsD,
+	"tag":           runTag,
+	"textfile":      runTextfile,
+	"version":       runVersion,
+	"watch":         runWatch,
+}
+
+func runCommand(name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q, expected one of: %s", name, strings.Join(commandNames(), ", "))
+	}
+
+	return cmd(args)
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/graphite"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	graphiteDefaultAddr     = "localhost:2003"
+	graphiteDefaultInterval = 60 * time.Second
+	graphiteDefaultTimeout  = 10 * time.Second
+)
+
+// runGraphite periodically sends snapshots to a Carbon plaintext receiver until interrupted.
+func runGraphite(args []string) error {
+	fs := flag.NewFlagSet("graphite", flag.ContinueOnError)
+	addr := fs.String("addr", graphiteDefaultAddr, "Carbon plaintext receiver as host:port")
+	prefix := fs.String("prefix", "", "Prefix of every metric path, e.g. servers.<hostname>")
+	tags := fs.String("tags", string(graphite.TagsPath),
+		"Label encoding: path (values appended to the path) or graphite (Graphite 1.1 tags)")
+	interval := fs.Duration("interval", graphiteDefaultInterval, "Send interval")
+	timeout := fs.Duration("timeout", graphiteDefaultTimeout, "Timeout of connecting and sending")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse graphite flags: %w", err)
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	client, err := graphite.New(*addr, graphite.TagStyle(*tags))
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	client.Prefix = *prefix
+	client.Timeout = *timeout
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	log.Printf("Sending to %s", *addr)
+
+	return emitEvery(ctx, *interval, collect, func(samples []prometheus.Sample, t time.Time) error {
+		return client.Send(ctx, samples, t)
+	})
+}
+
+// emitEvery collects a snapshot every interval and hands its samples to emit until ctx is done.
+func emitEvery(ctx context.Context, interval time.Duration, collect collectFunc,
+	emit func([]prometheus.Sample, time.Time) error,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		snap, err := collect()
+		if err == nil {
+			if err := snap.Err(); err != nil {
+				log.WithError(err).Warn("unable to collect some metrics")
+			}
+
+			err = emit(prometheus.Samples(snap), snap.Time)
+		}
+
+		if err != nil {
+			log.WithError(err).Error("unable to send metrics")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/web"
+	log "github.com/sirupsen/logrus"
+)
+
+// serveHTTP serves the exporter on -addr until interrupted. On SIGINT or SIGTERM it stops accepting
+// connections, waits up to -shutdown.timeout for in-flight scrapes and then stops the background
+// samplers, so that no mailbox call is cut off halfway. A second signal exits immediately.
+func serveHTTP() error {
+	webServer, err := web.NewServer(*flagWebConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go webServer.Watch(ctx, webConfigReloadInterval)
+
+	// The samplers outlive ctx so that scrapes still draining after a signal report their readings.
+	collect, stopCollector := newCollector(context.Background())
+	defer stopCollector()
+
+	ready := &readiness{}
+	cache := snapshot.NewCache(*flagMinInterval, ready.track(collect))
+	probe := snapshot.NewCache(*flagMinInterval, ready.track(newProbeCollector()))
+
+	http.Handle("/{$}", landingHandler())
+	http.Handle("/metrics", metricsHandler(cache))
+	http.Handle("/-/healthy", healthyHandler())
+	http.Handle("/-/ready", readyHandler(probe, ready, *flagReadyMaxAge))
+
+	srv := &http.Server{
+		Addr:         *flagAddr,
+		Handler:      webServer.Handler(http.DefaultServeMux),
+		ReadTimeout:  httpReadTimeout,
+		WriteTimeout: httpWriteTimeout,
+		IdleTimeout:  httpIdleTimeout,
+	}
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- webServer.ListenAndServe(srv)
+	}()
+
+	log.Printf("Listening on %s", *flagAddr)
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	stop()
+
+	log.Printf("Shutting down, waiting up to %s for in-flight scrapes", *flagShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *flagShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("unable to shut down http server: %w", err)
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// metricsHandler serves a snapshot from the cache in the exposition format negotiated from the
+// Accept header. The collect[] query parameters restrict the sections served.
+func metricsHandler(cache *snapshot.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var filter snapshot.Filter
+
+		if collect := r.URL.Query()["collect[]"]; len(collect) > 0 {
+			var err error
+
+			if filter, err = snapshot.NewFilter(collect...); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		snap, err := cache.Get()
+		if err != nil {
+			log.Printf("Error: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		if err := snap.Err(); err != nil {
+			log.WithError(err).Warn("unable to collect some metrics")
+		}
+
+		snap = snap.Filtered(filter)
+
+		format := prometheus.Negotiate(r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", format.ContentType())
+
+		if err := prometheus.RenderFormat(w, snap, format); err != nil {
+			log.WithError(err).Error("unable to write metrics")
+		}
+	}
+}
+
+// healthyHandler reports that the process is alive, without touching the mailbox.
+func healthyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "Healthy")
+	}
+}
+
+// readiness tracks the outcome of the last collection.
+type readiness struct {
+	mu sync.Mutex
+	// last is the time of the last successful collection.
+	last time.Time
+	err  error
+	// degraded holds the errors of individual readings of the last successful collection.
+	degraded error
+}
+
+// track returns collect recording its outcome. A collection in which every mailbox reading failed
+// is not successful.
+func (r *readiness) track(collect collectFunc) collectFunc {
+	return func() (*snapshot.Snapshot, error) {
+		snap, err := collect()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if err != nil {
+			r.err = err
+
+			return snap, err
+		}
+
+		if ok, failed := snap.Readings(); ok == 0 && failed > 0 {
+			r.err = fmt.Errorf("no successful readings: %w", snap.Err())
+
+			return snap, nil
+		}
+
+		r.err, r.last, r.degraded = nil, snap.Time, snap.Err()
+
+		return snap, nil
+	}
+}
+
+// check returns nil if the last collection succeeded within maxAge.
+func (r *readiness) check(maxAge time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case r.err != nil:
+		return r.err
+	case r.last.IsZero():
+		return errors.New("no collection yet")
+	case time.Since(r.last) > maxAge:
+		return fmt.Errorf("last successful collection was %s ago", time.Since(r.last).Round(time.Second))
+	}
+
+	return nil
+}
+
+// degradedBy returns the errors of individual readings of the last successful collection, or nil.
+func (r *readiness) degradedBy() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.degraded
+}
+
+// readyHandler reports whether the mailbox could be opened and the last collection succeeded within
+// maxAge. If no scrape collected within maxAge, it collects through probe itself, so probes sweep
+// the mailbox at most once per maxAge. A ready exporter whose last collection failed to read some
+// values is reported as degraded.
+func readyHandler(probe *snapshot.Cache, ready *readiness, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if ready.check(maxAge) != nil {
+			if _, err := probe.Get(); err != nil {
+				log.WithError(err).Debug("unable to collect for readiness")
+			}
+		}
+
+		if err := ready.check(maxAge); err != nil {
+			http.Error(w, "Not ready: "+err.Error(), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		if err := ready.degradedBy(); err != nil {
+			fmt.Fprintln(w, "Degraded: "+err.Error())
+
+			return
+		}
+
+		fmt.Fprintln(w, "Ready")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errReadyRead = errors.New("read failed")
+
+// readySnapshot returns a snapshot whose temperature readings fail as given.
+func readySnapshot(celsius, maxCelsius error) *snapshot.Snapshot {
+	now := time.Now()
+
+	return &snapshot.Snapshot{
+		Time:   now,
+		Filter: snapshot.Filter{snapshot.SectionTemperature: true},
+		Temperature: snapshot.Temperature{
+			Celsius:    snapshot.Reading[float32]{Value: 50, Time: now, Err: celsius},
+			MaxCelsius: snapshot.Reading[float32]{Value: 85, Time: now, Err: maxCelsius},
+		},
+	}
+}
+
+// probeReady serves a single readiness probe collecting through collect.
+func probeReady(t *testing.T, collect collectFunc) (*httptest.ResponseRecorder, int) {
+	t.Helper()
+
+	var probes int
+
+	ready := &readiness{}
+	probe := snapshot.NewCache(0, ready.track(func() (*snapshot.Snapshot, error) {
+		probes++
+
+		return collect()
+	}))
+
+	rec := httptest.NewRecorder()
+	readyHandler(probe, ready, time.Minute)(rec, httptest.NewRequest(http.MethodGet, "/-/ready", nil))
+
+	return rec, probes
+}
+
+func TestReadyHandler(t *testing.T) {
+	for name, test := range map[string]struct {
+		collect collectFunc
+		status  int
+		body    string
+	}{
+		"ready": {
+			collect: func() (*snapshot.Snapshot, error) { return readySnapshot(nil, nil), nil },
+			status:  http.StatusOK,
+			body:    "Ready",
+		},
+		"degraded": {
+			collect: func() (*snapshot.Snapshot, error) { return readySnapshot(nil, errReadyRead), nil },
+			status:  http.StatusOK,
+			body:    "Degraded: read failed",
+		},
+		"no successful readings": {
+			collect: func() (*snapshot.Snapshot, error) { return readySnapshot(errReadyRead, errReadyRead), nil },
+			status:  http.StatusServiceUnavailable,
+			body:    "Not ready: no successful readings",
+		},
+		"mailbox unavailable": {
+			collect: func() (*snapshot.Snapshot, error) { return nil, errReadyRead },
+			status:  http.StatusServiceUnavailable,
+			body:    "Not ready: read failed",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec, probes := probeReady(t, test.collect)
+
+			assert.Equal(t, 1, probes)
+			assert.Equal(t, test.status, rec.Code)
+			assert.Contains(t, rec.Body.String(), test.body)
+		})
+	}
+}
+
+func TestReadyHandlerUsesRecentCollection(t *testing.T) {
+	ready := &readiness{}
+
+	_, err := ready.track(func() (*snapshot.Snapshot, error) { return readySnapshot(nil, nil), nil })()
+	require.NoError(t, err)
+
+	probe := snapshot.NewCache(0, func() (*snapshot.Snapshot, error) {
+		t.Error("probe collected although a recent collection succeeded")
+
+		return readySnapshot(nil, nil), nil
+	})
+
+	rec := httptest.NewRecorder()
+	readyHandler(probe, ready, time.Minute)(rec, httptest.NewRequest(http.MethodGet, "/-/ready", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/influx"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	influxDefaultInterval = 15 * time.Second
+	influxDefaultTimeout  = 10 * time.Second
+)
+
+// runInflux periodically writes snapshots to an InfluxDB v2 bucket until interrupted.
+func runInflux(args []string) error {
+	tags := labelsFlag{}
+
+	fs := flag.NewFlagSet("influx", flag.ContinueOnError)
+	serverURL := fs.String("url", "", "InfluxDB server URL")
+	org := fs.String("org", "", "Organization name or ID")
+	bucket := fs.String("bucket", "", "Bucket name or ID")
+	tokenFile := fs.String("token-file", "", "File containing the API token")
+	interval := fs.Duration("interval", influxDefaultInterval, "Write interval")
+	timeout := fs.Duration("timeout", influxDefaultTimeout, "Timeout of a single request")
+	fs.Var(tags, "tag", "Tag added to every point as name=value, may be repeated (default host=<hostname>)")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse influx flags: %w", err)
+	}
+
+	if *serverURL == "" || *org == "" || *bucket == "" {
+		return errors.New("usage: rpi_exporter influx -url <server> -org <org> -bucket <bucket> [-token-file <file>]")
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	if _, ok := tags["host"]; !ok {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("unable to get hostname: %w", err)
+		}
+
+		tags["host"] = hostname
+	}
+
+	token, err := readSecretFile(*tokenFile)
+	if err != nil {
+		return err
+	}
+
+	client, err := influx.NewClient(*serverURL, *org, *bucket)
+	if err != nil {
+		return err
+	}
+
+	client.Token = token
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	log.Printf("Writing to %s", client.URL())
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		if err := writeInflux(ctx, client, collect, tags, *timeout); err != nil {
+			log.WithError(err).Error("unable to write metrics")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeInflux(ctx context.Context, client *influx.Client, collect collectFunc, tags map[string]string,
+	timeout time.Duration,
+) error {
+	snap, err := collect()
+	if err != nil {
+		return err
+	}
+
+	if err := snap.Err(); err != nil {
+		log.WithError(err).Warn("unable to collect some metrics")
+	}
+
+	var buf bytes.Buffer
+	if err := influx.RenderTags(&buf, snap, tags); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return client.Write(ctx, buf.Bytes())
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/version"
+	log "github.com/sirupsen/logrus"
+)
+
+// landingTemplate is the page served on /. Links are relative so that it also works behind a
+// reverse proxy serving the exporter below a path prefix.
+var landingTemplate = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>rpi_exporter</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+th { text-align: left; padding-right: 1em; }
+</style>
+</head>
+<body>
+<h1>rpi_exporter</h1>
+<p>Raspberry Pi hardware metrics from the VideoCore mailbox.</p>
+<table>
+<tr><th>Version</th><td>{{.Version}}</td></tr>
+<tr><th>Commit</th><td>{{.Commit}}</td></tr>
+<tr><th>Go</th><td>{{.GoVersion}}</td></tr>
+</table>
+<ul>
+<li><a href="metrics">Metrics</a></li>
+<li><a href="-/healthy">Health</a></li>
+<li><a href="-/ready">Readiness</a></li>
+</ul>
+<p><a href="https://github.com/schubergphilis/rpi_exporter">Source code</a></p>
+</body>
+</html>
+`))
+
+// landingHandler serves the landing page with the build information and links to the endpoints.
+func landingHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		if err := landingTemplate.Execute(w, version.Get()); err != nil {
+			log.WithError(err).Error("unable to render landing page")
+		}
+	}
+}
@@ -2,24 +2,49 @@ package main
 
 import (
 	"flag"
-	"net/http"
+	"fmt"
+	"io"
 	"os"
 	"time"
 
+	"github.com/schubergphilis/rpi_exporter/pkg/export/influx"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/json"
 	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
 	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
 	log "github.com/sirupsen/logrus"
 )
 
 var (
-	flagAddr  = flag.String("addr", "", "Listen on address")
-	flagDebug = flag.Bool("debug", false, "Print debug messages")
+	flagAddr   = flag.String("addr", "", "Listen on address")
+	flagDebug  = flag.Bool("debug", false, "Print debug messages")
+	flagFormat = flag.String("format", formatPrometheus, "Output format without -addr (prometheus, openmetrics, json, influx)")
+
+	flagMinInterval = flag.Duration("collect.min-interval", 0,
+		"Serve a cached snapshot to scrapes within this interval of the last collection")
+	flagWebConfig = flag.String("web.config.file", "",
+		"Web config file enabling TLS and basic authentication (exporter-toolkit web.yml format)")
+	flagShutdownTimeout = flag.Duration("shutdown.timeout", shutdownDefaultTimeout,
+		"Time to wait for in-flight scrapes on shutdown")
+	flagReadyMaxAge = flag.Duration("web.ready-max-age", readyDefaultMaxAge,
+		"Report not ready on /-/ready unless a collection succeeded within this duration")
+)
+
+const (
+	formatPrometheus  = "prometheus"
+	formatOpenMetrics = "openmetrics"
+	formatJSON        = "json"
+	formatInflux      = "influx"
 )
 
 const (
 	httpReadTimeout  = 5 * time.Second
 	httpWriteTimeout = 10 * time.Second
 	httpIdleTimeout  = 120 * time.Second
+
+	webConfigReloadInterval = 5 * time.Second
+	shutdownDefaultTimeout  = 15 * time.Second
+	readyDefaultMaxAge      = 60 * time.Second
 )
 
 func main() {
@@ -27,31 +52,53 @@ func main() {
 
 	mbox.Debug = *flagDebug
 
-	if *flagAddr != "" {
-		http.Handle("/metrics", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			if err := prometheus.Write(w); err != nil {
-				log.Printf("Error: %v", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-			}
-		}))
-
-		log.Printf("Listening on %s", *flagAddr)
-
-		srv := &http.Server{
-			Addr:         *flagAddr,
-			Handler:      nil,
-			ReadTimeout:  httpReadTimeout,
-			WriteTimeout: httpWriteTimeout,
-			IdleTimeout:  httpIdleTimeout,
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Arg(0), flag.Args()[1:]); err != nil {
+			log.Fatal(err)
 		}
-		if err := srv.ListenAndServe(); err != nil {
-			log.WithError(err).Fatal("unable to listen and serve http")
+
+		return
+	}
+
+	if *flagAddr != "" {
+		if err := serveHTTP(); err != nil {
+			log.Fatal(err)
 		}
 
 		return
 	}
 
-	if err := prometheus.Write(os.Stdout); err != nil {
+	if err := writeFormat(os.Stdout, *flagFormat); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// renderers maps output formats to their snapshot renderer.
+var renderers = map[string]func(io.Writer, *snapshot.Snapshot) error{
+	formatPrometheus: prometheus.Render,
+	formatOpenMetrics: func(w io.Writer, snap *snapshot.Snapshot) error {
+		return prometheus.RenderFormat(w, snap, prometheus.FormatOpenMetrics)
+	},
+	formatJSON:   json.Render,
+	formatInflux: influx.Render,
+}
+
+// writeFormat collects a snapshot and writes it to w in the given output format. Readings that
+// could not be collected are logged and omitted.
+func writeFormat(w io.Writer, format string) error {
+	render, ok := renderers[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	snap, err := snapshot.CollectFiltered(collectorFilter())
+	if err != nil {
+		return err
+	}
+
+	if err := snap.Err(); err != nil {
+		log.WithError(err).Warn("unable to collect some metrics")
+	}
+
+	return render(w, snap)
+}
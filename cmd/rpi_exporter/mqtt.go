@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/mqtt"
+	"github.com/schubergphilis/rpi_exporter/pkg/version"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	mqttDefaultInterval  = 30 * time.Second
+	mqttDefaultKeepAlive = 60 * time.Second
+	mqttDefaultTimeout   = 10 * time.Second
+	mqttDefaultTopic     = "rpi_exporter"
+	mqttDefaultDiscovery = "homeassistant"
+)
+
+// mqttPublisher keeps a broker connection and publishes snapshots over it, reconnecting as needed.
+type mqttPublisher struct {
+	broker  string
+	opts    mqtt.Options
+	topics  mqtt.Topics
+	timeout time.Duration
+
+	client *mqtt.Client
+	// discovered is set once the discovery configs are published on the current connection.
+	discovered bool
+}
+
+// runMQTT periodically publishes snapshots to an MQTT broker until interrupted.
+func runMQTT(args []string) error {
+	fs := flag.NewFlagSet("mqtt", flag.ContinueOnError)
+	broker := fs.String("broker", "", "Broker URL, e.g. tcp://localhost:1883 or mqtts://broker:8883")
+	nodeID := fs.String("node-id", "", "Node ID used in topics and discovery (default <hostname>)")
+	clientID := fs.String("client-id", "", "MQTT client ID (default rpi_exporter_<node-id>)")
+	topic := fs.String("topic", mqttDefaultTopic, "Prefix of the state topics")
+	discovery := fs.String("discovery-prefix", mqttDefaultDiscovery,
+		"Home Assistant discovery prefix, empty to disable discovery")
+	interval := fs.Duration("interval", mqttDefaultInterval, "Publish interval")
+	keepAlive := fs.Duration("keepalive", mqttDefaultKeepAlive, "Keep alive interval")
+	timeout := fs.Duration("timeout", mqttDefaultTimeout, "Timeout of connecting to the broker")
+	username := fs.String("username", "", "Username")
+	passwordFile := fs.String("password-file", "", "File containing the password")
+	caFile := fs.String("ca-file", "", "CA certificates to verify a TLS broker with (default system roots)")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse mqtt flags: %w", err)
+	}
+
+	if *broker == "" {
+		return errors.New("usage: rpi_exporter mqtt -broker <url> [-username <name> -password-file <file>]")
+	}
+
+	// MQTT 3.1.1 does not allow a password without a user name.
+	if *passwordFile != "" && *username == "" {
+		return errors.New("-password-file requires -username")
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	if *nodeID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("unable to get hostname: %w", err)
+		}
+
+		*nodeID = hostname
+	}
+
+	password, err := readSecretFile(*passwordFile)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := loadCAFile(*caFile)
+	if err != nil {
+		return err
+	}
+
+	topics := mqtt.Topics{
+		Base:            *topic,
+		DiscoveryPrefix: *discovery,
+		NodeID:          mqtt.NodeID(*nodeID),
+		Version:         version.Get().Version,
+	}
+
+	if *clientID == "" {
+		*clientID = "rpi_exporter_" + topics.NodeID
+	}
+
+	p := &mqttPublisher{
+		broker: *broker,
+		opts: mqtt.Options{
+			ClientID:  *clientID,
+			Username:  *username,
+			Password:  password,
+			KeepAlive: *keepAlive,
+			Will:      &mqtt.Message{Topic: topics.Availability(), Payload: []byte(mqtt.PayloadOffline), Retain: true},
+			TLSConfig: tlsConfig,
+		},
+		topics:  topics,
+		timeout: *timeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.publish(ctx, collect); err != nil {
+			log.WithError(err).Error("unable to publish metrics")
+			p.disconnect()
+		}
+
+		var lost <-chan struct{}
+		if p.client != nil {
+			lost = p.client.Done()
+		}
+
+		select {
+		case <-ctx.Done():
+			p.disconnect()
+
+			return nil
+		case <-lost:
+			log.WithError(p.client.Err()).Warn("lost connection to broker")
+
+			p.client = nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// loadCAFile returns a TLS config trusting the certificates in path, or nil if path is empty.
+func loadCAFile(path string) (*tls.Config, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ca file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// publish connects if needed and publishes a snapshot, preceded by the discovery configs on a new
+// connection.
+func (p *mqttPublisher) publish(ctx context.Context, collect collectFunc) error {
+	if p.client == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	snap, err := collect()
+	if err != nil {
+		return err
+	}
+
+	if err := snap.Err(); err != nil {
+		log.WithError(err).Warn("unable to collect some metrics")
+	}
+
+	msgs, err := p.topics.State(snap)
+	if err != nil {
+		return err
+	}
+
+	if !p.discovered {
+		discovery, err := p.topics.Discovery(snap)
+		if err != nil {
+			return err
+		}
+
+		msgs = append(discovery, msgs...)
+	}
+
+	for _, msg := range msgs {
+		if err := p.client.Publish(msg); err != nil {
+			return err
+		}
+	}
+
+	p.discovered = true
+
+	return nil
+}
+
+func (p *mqttPublisher) connect(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	client, err := mqtt.Dial(ctx, p.broker, p.opts)
+	if err != nil {
+		return err
+	}
+
+	online := mqtt.Message{Topic: p.topics.Availability(), Payload: []byte(mqtt.PayloadOnline), Retain: true}
+	if err := client.Publish(online); err != nil {
+		client.Close()
+
+		return err
+	}
+
+	log.Printf("Connected to %s as %s", p.broker, p.opts.ClientID)
+
+	p.client = client
+	p.discovered = false
+
+	return nil
+}
+
+// disconnect marks the node offline and closes the connection, if any.
+func (p *mqttPublisher) disconnect() {
+	if p.client == nil {
+		return
+	}
+
+	offline := mqtt.Message{Topic: p.topics.Availability(), Payload: []byte(mqtt.PayloadOffline), Retain: true}
+	if err := p.client.Publish(offline); err != nil {
+		log.WithError(err).Warn("unable to publish availability")
+	}
+
+	if err := p.client.Close(); err != nil {
+		log.WithError(err).Warn("unable to disconnect from broker")
+	}
+
+	p.client = nil
+}
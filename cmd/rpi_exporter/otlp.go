@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/otlp"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	otlpDefaultEndpoint  = "http://localhost:4318"
+	otlpMetricsPath      = "/v1/metrics"
+	otlpDefaultInterval  = 15 * time.Second
+	otlpDefaultBatchSize = 4
+	otlpDefaultMaxQueued = 240 // One hour at the default interval.
+	otlpDefaultTimeout   = 10 * time.Second
+)
+
+// otlpEndpoint returns the metrics endpoint configured by the standard OpenTelemetry environment
+// variables, or the default local collector.
+func otlpEndpoint() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + otlpMetricsPath
+	}
+
+	return otlpDefaultEndpoint + otlpMetricsPath
+}
+
+// otlpHeaders parses the headers in OTEL_EXPORTER_OTLP_HEADERS, a comma separated list of
+// URL-encoded name=value pairs.
+func otlpHeaders() (labelsFlag, error) {
+	headers := labelsFlag{}
+
+	env := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")
+	if env == "" {
+		return headers, nil
+	}
+
+	for _, pair := range strings.Split(env, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q in OTEL_EXPORTER_OTLP_HEADERS", pair)
+		}
+
+		value, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid header %q in OTEL_EXPORTER_OTLP_HEADERS: %w", pair, err)
+		}
+
+		headers[strings.TrimSpace(name)] = value
+	}
+
+	return headers, nil
+}
+
+// runOTLP periodically collects snapshots and exports them in batches to an OTLP/HTTP endpoint
+// until interrupted.
+func runOTLP(args []string) error {
+	headers, err := otlpHeaders()
+	if err != nil {
+		return err
+	}
+
+	attributes := labelsFlag{}
+
+	fs := flag.NewFlagSet("otlp", flag.ContinueOnError)
+	endpoint := fs.String("endpoint", otlpEndpoint(), "OTLP/HTTP metrics endpoint")
+	interval := fs.Duration("interval", otlpDefaultInterval, "Collection interval")
+	batchSize := fs.Int("batch-size", otlpDefaultBatchSize, "Number of collections exported per request")
+	maxQueued := fs.Int("max-queued", otlpDefaultMaxQueued,
+		"Maximum number of collections kept while the endpoint is unreachable (0 for no limit)")
+	timeout := fs.Duration("timeout", otlpDefaultTimeout, "Timeout of a single request")
+	gzip := fs.Bool("gzip", false, "Compress requests with gzip")
+	fs.Var(headers, "header", "Request header as name=value, may be repeated")
+	fs.Var(attributes, "attribute", "Resource attribute as name=value, may be repeated (default host.name=<hostname>)")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse otlp flags: %w", err)
+	}
+
+	if *interval <= 0 || *batchSize <= 0 {
+		return fmt.Errorf("interval and batch size must be positive, got %s and %d", *interval, *batchSize)
+	}
+
+	if _, ok := attributes["host.name"]; !ok {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("unable to get hostname: %w", err)
+		}
+
+		attributes["host.name"] = hostname
+	}
+
+	exporter, err := otlp.New(*endpoint)
+	if err != nil {
+		return err
+	}
+
+	exporter.Headers = headers
+	exporter.Attributes = attributes
+	exporter.Gzip = *gzip
+	exporter.MaxQueued = *maxQueued
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	log.Printf("Exporting to %s", exporter.URL())
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		if err := addOTLP(exporter, collect); err != nil {
+			log.WithError(err).Error("unable to collect metrics")
+		}
+
+		if exporter.Len() >= *batchSize {
+			flushOTLP(ctx, exporter, *timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			flushOTLP(context.Background(), exporter, *timeout)
+
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func addOTLP(exporter *otlp.Exporter, collect collectFunc) error {
+	snap, err := collect()
+	if err != nil {
+		return err
+	}
+
+	if err := snap.Err(); err != nil {
+		log.WithError(err).Warn("unable to collect some metrics")
+	}
+
+	if dropped := exporter.Add(snap); dropped > 0 {
+		log.Warnf("Queue full, dropped %d oldest collections", dropped)
+	}
+
+	return nil
+}
+
+func flushOTLP(ctx context.Context, exporter *otlp.Exporter, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := exporter.Flush(ctx); err != nil {
+		log.WithError(err).Errorf("unable to export metrics, %d collections queued", exporter.Len())
+	}
+}
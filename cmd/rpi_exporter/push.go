@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/pushgateway"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pushDefaultInterval = 15 * time.Second
+	pushDefaultJob      = "rpi_exporter"
+	pushDefaultRetries  = 3
+	pushDefaultBackoff  = time.Second
+	pushDefaultTimeout  = 10 * time.Second
+)
+
+// labelsFlag collects repeated name=value flags.
+type labelsFlag map[string]string
+
+func (l labelsFlag) String() string {
+	pairs := make([]string, 0, len(l))
+	for name, value := range l {
+		pairs = append(pairs, name+"="+value)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (l labelsFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid label %q, expected name=value", s)
+	}
+
+	l[name] = value
+
+	return nil
+}
+
+// readSecretFile returns the trimmed contents of a secret file, or an empty string if path is empty.
+func readSecretFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read secret file: %w", err)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// runPush periodically pushes the exposition to a Pushgateway until interrupted, and deletes the
+// group on exit.
+func runPush(args []string) error {
+	grouping := labelsFlag{}
+
+	fs := flag.NewFlagSet("push", flag.ContinueOnError)
+	gatewayURL := fs.String("url", "", "Pushgateway URL")
+	job := fs.String("job", pushDefaultJob, "Job name of the group")
+	interval := fs.Duration("interval", pushDefaultInterval, "Push interval")
+	retries := fs.Int("retries", pushDefaultRetries, "Retries of a failed push")
+	backoff := fs.Duration("backoff", pushDefaultBackoff, "Delay before the first retry, doubled on every retry")
+	timeout := fs.Duration("timeout", pushDefaultTimeout, "Timeout of a single request attempt, excluding retries")
+	username := fs.String("username", "", "Basic auth username")
+	passwordFile := fs.String("password-file", "", "File containing the basic auth password")
+	add := fs.Bool("add", false, "Replace only metrics with the same name (POST) instead of the whole group (PUT)")
+	fs.Var(grouping, "label", "Grouping label as name=value, may be repeated (default instance=<hostname>)")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse push flags: %w", err)
+	}
+
+	if *gatewayURL == "" {
+		return errors.New("usage: rpi_exporter push -url <pushgateway> [-label instance=<name>] [-label site=<name>]")
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	if _, ok := grouping["instance"]; !ok {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("unable to get hostname: %w", err)
+		}
+
+		grouping["instance"] = hostname
+	}
+
+	password, err := readSecretFile(*passwordFile)
+	if err != nil {
+		return err
+	}
+
+	pusher, err := pushgateway.New(*gatewayURL, *job, grouping)
+	if err != nil {
+		return err
+	}
+
+	pusher.Retries = *retries
+	pusher.Backoff = *backoff
+	pusher.Timeout = *timeout
+	pusher.Username = *username
+	pusher.Password = password
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	log.Printf("Pushing to %s", pusher.URL())
+
+	send := pusher.Push
+	if *add {
+		send = pusher.Add
+	}
+
+	return pushLoop(ctx, pusher, send, collect, *interval)
+}
+
+// pushSender sends a text exposition to the Pushgateway.
+type pushSender func(ctx context.Context, body []byte) error
+
+// pushLoop pushes every interval until ctx is done, and then deletes the group. Each attempt is
+// bounded by the timeout of pusher.
+func pushLoop(
+	ctx context.Context, pusher *pushgateway.Pusher, send pushSender, collect collectFunc, interval time.Duration,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := push(ctx, send, collect); err != nil {
+			log.WithError(err).Error("unable to push metrics")
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := pusher.Delete(context.Background()); err != nil {
+				return fmt.Errorf("unable to delete group: %w", err)
+			}
+
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func push(ctx context.Context, send pushSender, collect collectFunc) error {
+	snap, err := collect()
+	if err != nil {
+		return err
+	}
+
+	if err := snap.Err(); err != nil {
+		log.WithError(err).Warn("unable to collect some metrics")
+	}
+
+	var buf bytes.Buffer
+	if err := prometheus.Render(&buf, snap); err != nil {
+		return err
+	}
+
+	return send(ctx, buf.Bytes())
+}
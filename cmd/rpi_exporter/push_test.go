@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/pushgateway"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushLoopDeletesGroupOnShutdown(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		methods []string
+	)
+
+	pushed := make(chan struct{}, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+
+		if r.Method == http.MethodPut {
+			select {
+			case pushed <- struct{}{}:
+			default:
+			}
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	pusher, err := pushgateway.New(srv.URL, "rpi_exporter", map[string]string{"instance": "pi"})
+	require.NoError(t, err)
+
+	collect := func() (*snapshot.Snapshot, error) {
+		return &snapshot.Snapshot{Filter: snapshot.Filter{}}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- pushLoop(ctx, pusher, pusher.Push, collect, time.Hour)
+	}()
+
+	<-pushed
+	cancel()
+	require.NoError(t, <-done)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []string{http.MethodPut, http.MethodDelete}, methods)
+}
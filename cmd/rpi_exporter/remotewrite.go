@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/remotewrite"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	remoteWriteDefaultInterval   = 15 * time.Second
+	remoteWriteDefaultDir        = "/var/lib/rpi_exporter/remote_write"
+	remoteWriteDefaultMaxEntries = 5760 // One day at the default interval.
+	remoteWriteDefaultTimeout    = 30 * time.Second
+)
+
+// runRemoteWrite periodically collects a snapshot, buffers it on disk and sends all buffered
+// snapshots to a remote write endpoint until interrupted.
+func runRemoteWrite(args []string) error {
+	external := labelsFlag{}
+
+	fs := flag.NewFlagSet("remote_write", flag.ContinueOnError)
+	endpointURL := fs.String("url", "", "Remote write endpoint URL")
+	dir := fs.String("dir", remoteWriteDefaultDir, "Directory of the on-disk buffer")
+	maxEntries := fs.Int("max-entries", remoteWriteDefaultMaxEntries,
+		"Maximum number of buffered collections, the oldest are dropped first (0 for no limit)")
+	interval := fs.Duration("interval", remoteWriteDefaultInterval, "Collection interval")
+	timeout := fs.Duration("timeout", remoteWriteDefaultTimeout, "Timeout of a single request")
+	username := fs.String("username", "", "Basic auth username")
+	passwordFile := fs.String("password-file", "", "File containing the basic auth password")
+	bearerTokenFile := fs.String("bearer-token-file", "", "File containing a bearer token")
+	fs.Var(external, "label", "External label as name=value, may be repeated "+
+		"(default job=rpi_exporter and instance=<hostname>)")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse remote_write flags: %w", err)
+	}
+
+	if *endpointURL == "" {
+		return errors.New("usage: rpi_exporter remote_write -url <endpoint> [-dir <buffer>] [-label name=value]")
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	if _, ok := external["job"]; !ok {
+		external["job"] = pushDefaultJob
+	}
+
+	if _, ok := external["instance"]; !ok {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("unable to get hostname: %w", err)
+		}
+
+		external["instance"] = hostname
+	}
+
+	password, err := readSecretFile(*passwordFile)
+	if err != nil {
+		return err
+	}
+
+	token, err := readSecretFile(*bearerTokenFile)
+	if err != nil {
+		return err
+	}
+
+	queue, err := remotewrite.OpenQueue(*dir, *maxEntries)
+	if err != nil {
+		return err
+	}
+
+	sender, err := remotewrite.New(*endpointURL, queue)
+	if err != nil {
+		return err
+	}
+
+	sender.Username = *username
+	sender.Password = password
+	sender.BearerToken = token
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	log.Printf("Writing to %s, buffering in %s (%d collections buffered)", sender.URL(), *dir, queue.Len())
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		if err := enqueueRemoteWrite(sender, collect, external); err != nil {
+			log.WithError(err).Error("unable to buffer metrics")
+		}
+
+		flushRemoteWrite(ctx, sender, queue, *timeout)
+
+		select {
+		case <-ctx.Done():
+			// Give buffered collections one last chance; whatever is left is replayed on the next start.
+			flushRemoteWrite(context.Background(), sender, queue, *timeout)
+
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func enqueueRemoteWrite(sender *remotewrite.Sender, collect collectFunc, external map[string]string) error {
+	snap, err := collect()
+	if err != nil {
+		return err
+	}
+
+	if err := snap.Err(); err != nil {
+		log.WithError(err).Warn("unable to collect some metrics")
+	}
+
+	req, err := prometheus.WriteRequest(snap, external)
+	if err != nil {
+		return err
+	}
+
+	dropped, err := sender.Enqueue(req)
+	if dropped > 0 {
+		log.Warnf("Buffer full, dropped %d oldest collections", dropped)
+	}
+
+	return err
+}
+
+func flushRemoteWrite(ctx context.Context, sender *remotewrite.Sender, queue *remotewrite.Queue, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sent, err := sender.Flush(ctx)
+	if err != nil {
+		log.WithError(err).Errorf("unable to send metrics, %d collections buffered", queue.Len())
+	}
+
+	if sent > 1 {
+		log.Printf("Replayed %d buffered collections", sent)
+	}
+}
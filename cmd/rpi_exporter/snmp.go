@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snmp"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	snmpDefaultAddr        = ":161"
+	snmpDefaultMinInterval = 5 * time.Second
+)
+
+// rootFlag is the -root flag of the snmp subcommands.
+func rootFlag(fs *flag.FlagSet) *string {
+	return fs.String("root", snmp.DefaultRoot.String(),
+		"OID of the RPI-EXPORTER-MIB module. The default is a placeholder under netSnmpPlaypen for lab use; "+
+			"set an arc under your own enterprise number")
+}
+
+// runSNMP serves the hardware snapshot to SNMPv2c managers until interrupted.
+func runSNMP(args []string) error {
+	fs := flag.NewFlagSet("snmp", flag.ContinueOnError)
+	addr := fs.String("addr", snmpDefaultAddr, "UDP address to listen on")
+	community := fs.String("community", "", "Read-only community; required unless -community-file is set")
+	communityFile := fs.String("community-file", "", "File containing the read-only community")
+	root := rootFlag(fs)
+	minInterval := fs.Duration("collect.min-interval", snmpDefaultMinInterval,
+		"Minimum interval between collections; requests within it share one snapshot")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse snmp flags: %w", err)
+	}
+
+	rootOID, err := snmp.ParseOID(*root)
+	if err != nil {
+		return err
+	}
+
+	if *communityFile != "" {
+		if *community, err = readSecretFile(*communityFile); err != nil {
+			return err
+		}
+	}
+
+	if *community == "" {
+		return errors.New("usage: rpi_exporter snmp -community <community> | -community-file <file> [-addr :161]")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	cache := snapshot.NewCache(*minInterval, collect)
+
+	agent := snmp.NewAgent(*community, cache.Get)
+	agent.Root = rootOID
+
+	conn, err := net.ListenPacket("udp", *addr)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s: %w", *addr, err)
+	}
+
+	log.Printf("Serving SNMP on %s under %s", conn.LocalAddr(), rootOID)
+
+	return agent.Serve(ctx, conn)
+}
+
+// runSNMPMIB prints RPI-EXPORTER-MIB.
+func runSNMPMIB(args []string) error {
+	fs := flag.NewFlagSet("snmp_mib", flag.ContinueOnError)
+	root := rootFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse snmp_mib flags: %w", err)
+	}
+
+	rootOID, err := snmp.ParseOID(*root)
+	if err != nil {
+		return err
+	}
+
+	return snmp.WriteMIB(os.Stdout, rootOID)
+}
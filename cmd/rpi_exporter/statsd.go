@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/statsd"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	statsdDefaultAddr     = "localhost:8125"
+	statsdDefaultInterval = 10 * time.Second
+)
+
+// runStatsD periodically sends snapshots as gauges to a StatsD server until interrupted.
+func runStatsD(args []string) error {
+	fs := flag.NewFlagSet("statsd", flag.ContinueOnError)
+	addr := fs.String("addr", statsdDefaultAddr, "StatsD server as host:port")
+	prefix := fs.String("prefix", "", "Prefix of every metric name")
+	tags := fs.String("tags", string(statsd.TagsDogStatsD),
+		"Label encoding: dogstatsd, influx (Telegraf) or none (values appended to the name)")
+	interval := fs.Duration("interval", statsdDefaultInterval, "Send interval")
+	maxPacketSize := fs.Int("max-packet-size", statsd.DefaultMaxPacketSize, "Maximum size of a datagram in bytes")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse statsd flags: %w", err)
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	client, err := statsd.Dial(*addr, statsd.TagStyle(*tags))
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	client.Prefix = *prefix
+	client.MaxPacketSize = *maxPacketSize
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	log.Printf("Sending to %s", *addr)
+
+	return emitEvery(ctx, *interval, collect, func(samples []prometheus.Sample, _ time.Time) error {
+		return client.Send(samples)
+	})
+}
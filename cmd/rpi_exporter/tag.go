@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+)
+
+const (
+	tagDefaultBufferBytes = 8
+	asciiPrintableFirst   = 0x20
+	asciiPrintableLast    = 0x7e
+)
+
+var errTagUsage = errors.New("usage: rpi_exporter tag [-size bytes] [-json] <id> [args...]")
+
+// tagResponse is the decoded form of a single response tag.
+type tagResponse struct {
+	ID       uint32   `json:"id"`
+	Cap      int      `json:"cap"`
+	Len      int      `json:"len"`
+	Response bool     `json:"response"`
+	Values   []uint32 `json:"values"`
+	Hex      []string `json:"hex"`
+	ASCII    string   `json:"ascii"`
+}
+
+// runTag sends a single raw tag to the mailbox and prints the response tags.
+func runTag(args []string) error {
+	fs := flag.NewFlagSet("tag", flag.ContinueOnError)
+	size := fs.Int("size", tagDefaultBufferBytes, "Value buffer size in bytes (multiple of 4)")
+	asJSON := fs.Bool("json", false, "Print response tags as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse tag flags: %w", err)
+	}
+
+	if fs.NArg() < 1 {
+		return errTagUsage
+	}
+
+	if *size < 0 || *size%mbox.MailboxWordBytes != 0 || *size > mbox.MailboxMaxValueBytes {
+		return fmt.Errorf("buffer size must be a multiple of %d between 0 and %d bytes",
+			mbox.MailboxWordBytes, mbox.MailboxMaxValueBytes)
+	}
+
+	words, err := parseWords(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	m, err := mbox.Open()
+	if err != nil {
+		return fmt.Errorf("unable to open mbox: %w", err)
+	}
+
+	defer m.Close()
+
+	tags, err := m.Do(words[0], *size, words[1:]...)
+	if err != nil {
+		return fmt.Errorf("unable to query tag 0x%08x: %w", words[0], err)
+	}
+
+	responses := make([]tagResponse, 0, len(tags))
+	for _, tag := range tags {
+		responses = append(responses, decodeTag(tag))
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(responses); err != nil {
+			return fmt.Errorf("unable to encode tags: %w", err)
+		}
+
+		return nil
+	}
+
+	printTags(os.Stdout, responses)
+
+	return nil
+}
+
+// parseWords parses decimal, hex (0x), octal (0o) or binary (0b) 32-bit words.
+func parseWords(args []string) ([]uint32, error) {
+	words := make([]uint32, 0, len(args))
+
+	for _, arg := range args {
+		v, err := strconv.ParseUint(arg, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 32-bit word %q: %w", arg, err)
+		}
+
+		words = append(words, uint32(v))
+	}
+
+	return words, nil
+}
+
+func decodeTag(tag mbox.Tag) tagResponse {
+	values := tag.Value()
+
+	hex := make([]string, 0, len(values))
+	for _, v := range values {
+		hex = append(hex, fmt.Sprintf("0x%08x", v))
+	}
+
+	return tagResponse{
+		ID:       tag.ID(),
+		Cap:      tag.Cap(),
+		Len:      tag.Len(),
+		Response: tag.IsResponse(),
+		Values:   append([]uint32{}, values...),
+		Hex:      hex,
+		ASCII:    printableASCII(tag.Bytes()),
+	}
+}
+
+// printableASCII renders bytes as ASCII, replacing non-printable characters with a dot.
+func printableASCII(b []byte) string {
+	var sb strings.Builder
+
+	for _, c := range b {
+		if c < asciiPrintableFirst || c > asciiPrintableLast {
+			c = '.'
+		}
+
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
+
+func printTags(w io.Writer, responses []tagResponse) {
+	for _, r := range responses {
+		fmt.Fprintf(w, "tag 0x%08x cap=%d len=%d response=%t\n", r.ID, r.Cap, r.Len, r.Response)
+
+		for i, v := range r.Values {
+			fmt.Fprintf(w, "  [%02d] %s %10d\n", i, r.Hex[i], v)
+		}
+
+		fmt.Fprintf(w, "  ascii: %q\n", r.ASCII)
+	}
+}
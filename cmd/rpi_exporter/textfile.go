@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	textfileDefaultInterval = 15 * time.Second
+	textfileDefaultName     = "rpi_exporter.prom"
+	textfileDefaultMode     = "0644"
+)
+
+// parseFileMode parses octal permission bits. Setuid, setgid and sticky bits are rejected, since
+// os.FileMode does not represent them in the same bits.
+func parseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q: %w", s, err)
+	}
+
+	if os.FileMode(mode)&^os.ModePerm != 0 {
+		return 0, fmt.Errorf("invalid file mode %q, only permission bits up to 0777 are allowed", s)
+	}
+
+	return os.FileMode(mode), nil
+}
+
+// runTextfile periodically writes the exposition into a node_exporter textfile collector
+// directory until interrupted, and removes the file on exit.
+func runTextfile(args []string) error {
+	fs := flag.NewFlagSet("textfile", flag.ContinueOnError)
+	dir := fs.String("dir", "", "node_exporter textfile collector directory")
+	name := fs.String("name", textfileDefaultName, "File name, must end in .prom")
+	interval := fs.Duration("interval", textfileDefaultInterval, "Write interval")
+	modeStr := fs.String("mode", textfileDefaultMode, "File permissions (octal)")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse textfile flags: %w", err)
+	}
+
+	if *dir == "" {
+		return errors.New("usage: rpi_exporter textfile -dir <directory> [-interval 15s] [-mode 0644]")
+	}
+
+	if filepath.Ext(*name) != ".prom" || filepath.Base(*name) != *name {
+		return fmt.Errorf("invalid file name %q, must be a base name ending in .prom", *name)
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	mode, err := parseFileMode(*modeStr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	path := filepath.Join(*dir, *name)
+	collect, stopCollector := newCollector(ctx)
+	defer stopCollector()
+
+	defer func() {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.WithError(err).Error("unable to remove textfile")
+		}
+	}()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		if err := writeTextfile(path, mode, collect); err != nil {
+			log.WithError(err).Error("unable to write textfile")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeTextfile writes the exposition to a hidden temporary file in the same directory and renames
+// it over path, so that node_exporter never reads a partial file.
+func writeTextfile(path string, mode os.FileMode, collect collectFunc) error {
+	snap, err := collect()
+	if err != nil {
+		return err
+	}
+
+	if err := snap.Err(); err != nil {
+		log.WithError(err).Warn("unable to collect some metrics")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if err := prometheus.RenderTextfile(tmp, snap); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("unable to set file mode: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("unable to rename temporary file: %w", err)
+	}
+
+	return nil
+}
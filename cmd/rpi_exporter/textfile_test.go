@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileMode(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want os.FileMode
+	}{
+		{"0644", 0o644},
+		{"600", 0o600},
+		{"0777", 0o777},
+		{"0", 0},
+	} {
+		mode, err := parseFileMode(test.in)
+		require.NoError(t, err, test.in)
+		assert.Equal(t, test.want, mode, test.in)
+	}
+
+	for _, in := range []string{"4755", "1777", "2644", "01000", "0o644", "rw-r--r--", "0888", ""} {
+		_, err := parseFileMode(in)
+		require.Error(t, err, in)
+		assert.Contains(t, err.Error(), "invalid file mode", in)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+)
+
+// vcgencmd clock identifiers as printed by "vcgencmd measure_clock".
+var vcgencmdClocks = map[string]struct {
+	id   mbox.ClockID
+	code int
+}{
+	"arm":   {mbox.ClockIDARM, 48},
+	"core":  {mbox.ClockIDCore, 1},
+	"h264":  {mbox.ClockIDH264, 28},
+	"isp":   {mbox.ClockIDISP, 45},
+	"v3d":   {mbox.ClockIDV3D, 46},
+	"uart":  {mbox.ClockIDUART, 22},
+	"pwm":   {mbox.ClockIDPWM, 25},
+	"emmc":  {mbox.ClockIDEMMC, 50},
+	"pixel": {mbox.ClockIDPixel, 29},
+}
+
+var vcgencmdVoltages = map[string]mbox.VoltageID{
+	"core":    mbox.VoltageIDCore,
+	"sdram_c": mbox.VoltageIDSDRAMC,
+	"sdram_i": mbox.VoltageIDSDRAMI,
+	"sdram_p": mbox.VoltageIDSDRAMP,
+}
+
+// vcgencmdDateLayout matches the C __DATE__ and __TIME__ macros used by the firmware build.
+const vcgencmdDateLayout = "Jan _2 2006 15:04:05"
+
+// withMailbox opens the mailbox for the duration of fn.
+func withMailbox(fn func(m *mbox.Mailbox) error) error {
+	m, err := mbox.Open()
+	if err != nil {
+		return fmt.Errorf("unable to open mbox: %w", err)
+	}
+
+	defer m.Close()
+
+	return fn(m)
+}
+
+func runMeasureTemp(_ []string) error {
+	return withMailbox(func(m *mbox.Mailbox) error {
+		temp, err := m.GetTemperature()
+		if err != nil {
+			return fmt.Errorf("unable to get temperature: %w", err)
+		}
+
+		fmt.Fprintf(os.Stdout, "temp=%.1f'C\n", temp)
+
+		return nil
+	})
+}
+
+func runMeasureClock(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: rpi_exporter measure_clock <clock>")
+	}
+
+	clock, ok := vcgencmdClocks[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown clock %q", args[0])
+	}
+
+	return withMailbox(func(m *mbox.Mailbox) error {
+		rate, err := m.GetClockRateMeasured(clock.id)
+		if err != nil {
+			return fmt.Errorf("unable to get measured clock rate: %w", err)
+		}
+
+		fmt.Fprintf(os.Stdout, "frequency(%d)=%d\n", clock.code, rate)
+
+		return nil
+	})
+}
+
+func runMeasureVolts(args []string) error {
+	name := "core"
+
+	switch len(args) {
+	case 0:
+	case 1:
+		name = args[0]
+	default:
+		return errors.New("usage: rpi_exporter measure_volts [core|sdram_c|sdram_i|sdram_p]")
+	}
+
+	id, ok := vcgencmdVoltages[name]
+	if !ok {
+		return fmt.Errorf("unknown voltage %q", name)
+	}
+
+	return withMailbox(func(m *mbox.Mailbox) error {
+		volts, err := m.GetVoltage(id)
+		if err != nil {
+			return fmt.Errorf("unable to get voltage: %w", err)
+		}
+
+		fmt.Fprintf(os.Stdout, "volt=%.4fV\n", volts)
+
+		return nil
+	})
+}
+
+func runGetThrottled(_ []string) error {
+	return withMailbox(func(m *mbox.Mailbox) error {
+		throttled, err := m.GetThrottled()
+		if err != nil {
+			return fmt.Errorf("unable to get throttled state: %w", err)
+		}
+
+		fmt.Fprintf(os.Stdout, "throttled=0x%x\n", throttled)
+
+		return nil
+	})
+}
+
+// runVersion prints the firmware version. The mailbox does not report the build flags that vcgencmd
+// prints, so a clean release build is assumed.
+func runVersion(_ []string) error {
+	return withMailbox(func(m *mbox.Mailbox) error {
+		rev, err := m.GetFirmwareRevision()
+		if err != nil {
+			return fmt.Errorf("unable to get firmware revision: %w", err)
+		}
+
+		hash, err := m.GetFirmwareHash()
+		if err != nil {
+			return fmt.Errorf("unable to get firmware hash: %w", err)
+		}
+
+		variant, err := m.GetFirmwareVariant()
+		if err != nil {
+			return fmt.Errorf("unable to get firmware variant: %w", err)
+		}
+
+		fmt.Fprintf(os.Stdout, "%s \n", time.Unix(int64(rev), 0).UTC().Format(vcgencmdDateLayout))
+		fmt.Fprintln(os.Stdout, "Copyright (c) 2012 Broadcom")
+		fmt.Fprintf(os.Stdout, "version %s (clean) (release) (%s)\n", hash, variant)
+
+		return nil
+	})
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+)
+
+const (
+	watchDefaultInterval = time.Second
+	watchClearScreen     = "\033[H\033[2J"
+	watchTabPadding      = 2
+	hzPerMHz             = 1e6
+)
+
+// watchSource is the part of the mailbox polled by the watch command.
+type watchSource interface {
+	GetTemperature() (float32, error)
+	GetClockRateMeasured(id mbox.ClockID) (int, error)
+	GetVoltage(id mbox.VoltageID) (float32, error)
+	GetThrottled() (uint32, error)
+}
+
+// watchStat tracks the minimum, maximum and average of a reading since start.
+type watchStat struct {
+	name string
+	unit string
+	read func(watchSource) (float64, error)
+	last float64
+	min  float64
+	max  float64
+	sum  float64
+	n    int
+	// failed is set if the last read failed, errors counts all failed reads.
+	failed bool
+	errors int
+}
+
+func clockStat(name string, id mbox.ClockID) *watchStat {
+	return &watchStat{name: name, unit: "MHz", read: func(src watchSource) (float64, error) {
+		rate, err := src.GetClockRateMeasured(id)
+
+		return float64(rate) / hzPerMHz, err
+	}}
+}
+
+func voltageStat(name string, id mbox.VoltageID) *watchStat {
+	return &watchStat{name: name, unit: "V", read: func(src watchSource) (float64, error) {
+		volts, err := src.GetVoltage(id)
+
+		return float64(volts), err
+	}}
+}
+
+func newWatchStats() []*watchStat {
+	return []*watchStat{
+		{name: "temperature", unit: "'C", read: func(src watchSource) (float64, error) {
+			temp, err := src.GetTemperature()
+
+			return float64(temp), err
+		}},
+		clockStat("arm clock", mbox.ClockIDARM),
+		clockStat("core clock", mbox.ClockIDCore),
+		voltageStat("core volts", mbox.VoltageIDCore),
+		voltageStat("sdram_c volts", mbox.VoltageIDSDRAMC),
+		voltageStat("sdram_i volts", mbox.VoltageIDSDRAMI),
+		voltageStat("sdram_p volts", mbox.VoltageIDSDRAMP),
+	}
+}
+
+func (s *watchStat) add(v float64) {
+	if s.n == 0 {
+		s.min, s.max = v, v
+	}
+
+	s.last = v
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+	s.sum += v
+	s.n++
+}
+
+func (s *watchStat) avg() float64 {
+	if s.n == 0 {
+		return 0
+	}
+
+	return s.sum / float64(s.n)
+}
+
+// watchState holds all readings collected by the watch command.
+type watchState struct {
+	start     time.Time
+	samples   int
+	stats     []*watchStat
+	throttled uint32
+	seen      uint32
+	// throttledFailed is set if the last read of the throttled flags failed.
+	throttledFailed bool
+	throttledErrors int
+}
+
+var throttledFlagNames = []struct {
+	flag uint32
+	name string
+}{
+	{mbox.ThrottledUnderVoltage, "under-voltage"},
+	{mbox.ThrottledFreqCapped, "freq-capped"},
+	{mbox.ThrottledThrottled, "throttled"},
+	{mbox.ThrottledSoftTempLimit, "soft-temp-limit"},
+}
+
+func formatThrottled(flags uint32) string {
+	var names []string
+
+	for _, f := range throttledFlagNames {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+
+	return strings.Join(names, ",")
+}
+
+// runWatch polls the mailbox and renders a refreshing table until interrupted.
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := fs.Duration("interval", watchDefaultInterval, "Polling interval")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("unable to parse watch flags: %w", err)
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return withMailbox(func(m *mbox.Mailbox) error {
+		state := &watchState{start: time.Now(), stats: newWatchStats()}
+
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+
+		for {
+			state.sample(m)
+
+			fmt.Fprint(os.Stdout, watchClearScreen)
+			state.render(os.Stdout)
+
+			select {
+			case <-ctx.Done():
+				fmt.Fprintln(os.Stdout)
+				state.summary(os.Stdout)
+
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// sample reads all stats from src. Failed reads are counted and shown on their row, without ending
+// the watch.
+func (s *watchState) sample(src watchSource) {
+	for _, st := range s.stats {
+		v, err := st.read(src)
+
+		st.failed = err != nil
+		if err != nil {
+			st.errors++
+
+			continue
+		}
+
+		st.add(v)
+	}
+
+	throttled, err := src.GetThrottled()
+
+	s.throttledFailed = err != nil
+	if err != nil {
+		s.throttledErrors++
+	} else {
+		s.throttled = throttled
+		s.seen |= throttled
+	}
+
+	s.samples++
+}
+
+// errors returns the number of failed reads.
+func (s *watchState) errors() int {
+	n := s.throttledErrors
+	for _, st := range s.stats {
+		n += st.errors
+	}
+
+	return n
+}
+
+func (s *watchState) writeTable(w io.Writer, current bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, watchTabPadding, ' ', tabwriter.AlignRight)
+
+	if current {
+		fmt.Fprintln(tw, "\tcurrent\tmin\tmax\tavg\terrors\t")
+	} else {
+		fmt.Fprintln(tw, "\tmin\tmax\tavg\terrors\t")
+	}
+
+	for _, st := range s.stats {
+		if current {
+			last := fmt.Sprintf("%.3f", st.last)
+			if st.failed {
+				last = "error"
+			}
+
+			fmt.Fprintf(tw, "%s (%s)\t%s\t%.3f\t%.3f\t%.3f\t%d\t\n", st.name, st.unit, last, st.min, st.max, st.avg(),
+				st.errors)
+		} else {
+			fmt.Fprintf(tw, "%s (%s)\t%.3f\t%.3f\t%.3f\t%d\t\n", st.name, st.unit, st.min, st.max, st.avg(), st.errors)
+		}
+	}
+
+	tw.Flush()
+}
+
+func (s *watchState) render(w io.Writer) {
+	fmt.Fprintf(w, "rpi_exporter watch: %d samples in %s (Ctrl-C to exit)\n\n",
+		s.samples, time.Since(s.start).Round(time.Second))
+	s.writeTable(w, true)
+
+	if s.throttledFailed {
+		fmt.Fprintf(w, "\nthrottled=error (%d errors)\n", s.throttledErrors)
+	} else {
+		fmt.Fprintf(w, "\nthrottled=0x%x (%s)\n", s.throttled, formatThrottled(s.throttled))
+	}
+
+	fmt.Fprintf(w, "since start: %s\n", formatThrottled(s.seen))
+}
+
+func (s *watchState) summary(w io.Writer) {
+	fmt.Fprintf(w, "Summary: %d samples in %s, %d read errors\n\n", s.samples, time.Since(s.start).Round(time.Second),
+		s.errors())
+	s.writeTable(w, false)
+	fmt.Fprintf(w, "\nthrottle flags seen: %s\n", formatThrottled(s.seen))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+	"github.com/stretchr/testify/assert"
+)
+
+var errWatchRead = errors.New("read failed")
+
+// flakySource fails reads of the temperature and the throttled flags while failing is set.
+type flakySource struct {
+	failing bool
+}
+
+func (f *flakySource) GetTemperature() (float32, error) {
+	if f.failing {
+		return 0, errWatchRead
+	}
+
+	return 50, nil
+}
+
+func (f *flakySource) GetClockRateMeasured(mbox.ClockID) (int, error) { return 1500000000, nil }
+
+func (f *flakySource) GetVoltage(mbox.VoltageID) (float32, error) { return 0.86, nil }
+
+func (f *flakySource) GetThrottled() (uint32, error) {
+	if f.failing {
+		return 0, errWatchRead
+	}
+
+	return mbox.ThrottledUnderVoltage, nil
+}
+
+func TestWatchSampleKeepsPollingOnErrors(t *testing.T) {
+	src := &flakySource{}
+	state := &watchState{stats: newWatchStats()}
+
+	state.sample(src)
+
+	src.failing = true
+	state.sample(src)
+
+	assert.Equal(t, 2, state.samples)
+	assert.True(t, state.stats[0].failed)
+	assert.Equal(t, 1, state.stats[0].errors)
+	assert.Equal(t, 1, state.stats[0].n)
+	assert.Equal(t, 2, state.stats[1].n)
+	assert.True(t, state.throttledFailed)
+	assert.Equal(t, 2, state.errors())
+
+	var out bytes.Buffer
+
+	state.render(&out)
+	assert.Contains(t, out.String(), "error")
+	assert.Contains(t, out.String(), "throttled=error (1 errors)")
+
+	out.Reset()
+	state.summary(&out)
+	assert.Contains(t, out.String(), "2 samples")
+	assert.Contains(t, out.String(), "2 read errors")
+	assert.Contains(t, out.String(), "under-voltage")
+}
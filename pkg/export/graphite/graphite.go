@@ -0,0 +1,150 @@
+/*
+Package graphite emits samples in the Graphite plaintext protocol over TCP, with labels either
+folded into the metric path or sent as Graphite 1.1 tags. The protocol is documented here:
+
+https://graphite.readthedocs.io/en/latest/feeding-carbon.html
+*/
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+)
+
+// TagStyle selects how labels are encoded.
+type TagStyle string
+
+const (
+	// TagsPath appends label values to the metric path, separated by dots.
+	TagsPath TagStyle = "path"
+	// TagsGraphite appends Graphite 1.1 tags as ;name=value.
+	TagsGraphite TagStyle = "graphite"
+)
+
+// TagStyles lists the supported tag styles.
+var TagStyles = []TagStyle{TagsPath, TagsGraphite}
+
+var (
+	invalidPathChars = regexp.MustCompile(`[^a-zA-Z0-9_.+-]`)
+	invalidTagChars  = regexp.MustCompile(`[;~!^=\s]`)
+)
+
+// Client sends samples to a Carbon plaintext receiver, keeping the connection open between sends.
+type Client struct {
+	// Prefix is prepended to every metric path, separated by a dot.
+	Prefix string
+	// Tags selects how labels are encoded.
+	Tags TagStyle
+	// Timeout limits connecting and sending.
+	Timeout time.Duration
+
+	addr string
+	conn net.Conn
+}
+
+// New returns a client sending to the Carbon receiver at addr, a host:port pair. It connects on
+// the first Send.
+func New(addr string, tags TagStyle) (*Client, error) {
+	if !slices.Contains(TagStyles, tags) {
+		return nil, fmt.Errorf("unknown tag style %q", tags)
+	}
+
+	return &Client{Tags: tags, addr: addr}, nil
+}
+
+// Send sends samples timestamped with t. The connection is closed on failure and re-established by
+// the next Send.
+func (c *Client) Send(ctx context.Context, samples []prometheus.Sample, t time.Time) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	if c.conn == nil {
+		var d net.Dialer
+
+		conn, err := d.DialContext(ctx, "tcp", c.addr)
+		if err != nil {
+			return fmt.Errorf("unable to connect to graphite: %w", err)
+		}
+
+		c.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.conn.SetWriteDeadline(deadline); err != nil {
+			return fmt.Errorf("unable to set deadline: %w", err)
+		}
+	}
+
+	w := bufio.NewWriter(c.conn)
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	for _, s := range samples {
+		// Carbon has no representation of NaN or infinity.
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+
+		// Write errors are sticky and reported by Flush.
+		_, _ = w.WriteString(c.path(s) + " " + strconv.FormatFloat(s.Value, 'f', -1, 64) + " " + timestamp + "\n")
+	}
+
+	if err := w.Flush(); err != nil {
+		c.Close()
+
+		return fmt.Errorf("unable to send metrics: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) path(s prometheus.Sample) string {
+	path := s.Name
+	if c.Prefix != "" {
+		path = c.Prefix + "." + path
+	}
+
+	var tags string
+
+	for _, l := range s.Labels {
+		switch c.Tags {
+		case TagsPath:
+			path += "." + invalidPathChars.ReplaceAllString(l.Value, "_")
+		case TagsGraphite:
+			// Empty tag values are not allowed.
+			if l.Value != "" {
+				tags += ";" + l.Name + "=" + invalidTagChars.ReplaceAllString(l.Value, "_")
+			}
+		}
+	}
+
+	return invalidPathChars.ReplaceAllString(path, "_") + tags
+}
+
+// Close closes the connection, if any.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+
+	if err != nil {
+		return fmt.Errorf("unable to close connection: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sendTime = time.Unix(1700000000, 0)
+
+func sample(name string, value float64, labels ...string) prometheus.Sample {
+	s := prometheus.Sample{Name: name, Value: value}
+
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.Labels = append(s.Labels, prometheus.Label{Name: labels[i], Value: labels[i+1]})
+	}
+
+	return s
+}
+
+// carbon is a Carbon receiver stand-in that passes the received lines of each connection to lines.
+func carbon(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	lines := make(chan string, 100)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String(), lines
+}
+
+func receive(t *testing.T, lines <-chan string, n int) []string {
+	t.Helper()
+
+	var out []string
+
+	for range n {
+		select {
+		case line := <-lines:
+			out = append(out, line)
+		case <-time.After(time.Second):
+			require.Failf(t, "timeout", "received %d of %d lines: %q", len(out), n, out)
+		}
+	}
+
+	return out
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("127.0.0.1:2003", "dogstatsd")
+	require.Error(t, err)
+
+	c, err := New("127.0.0.1:2003", TagsPath)
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+}
+
+func TestPath(t *testing.T) {
+	samples := []prometheus.Sample{
+		sample("rpi_temperature_celsius", 48.3, "id", "soc"),
+		sample("rpi_clock_rate_hz", 1500000000, "id", "arm", "kind", "set point"),
+		sample("rpi_up", 1),
+		sample("rpi_info", 1, "hash", "a;b~c!d^e=f/g+h-i.j"),
+		sample("rpi bad/name", 0),
+	}
+
+	tests := []struct {
+		tags   TagStyle
+		prefix string
+		want   []string
+	}{
+		{
+			tags:   TagsPath,
+			prefix: "pi.lab",
+			want: []string{
+				"pi.lab.rpi_temperature_celsius.soc",
+				"pi.lab.rpi_clock_rate_hz.arm.set_point",
+				"pi.lab.rpi_up",
+				"pi.lab.rpi_info.a_b_c_d_e_f_g+h-i.j",
+				"pi.lab.rpi_bad_name",
+			},
+		},
+		{
+			tags: TagsGraphite,
+			want: []string{
+				"rpi_temperature_celsius;id=soc",
+				"rpi_clock_rate_hz;id=arm;kind=set_point",
+				"rpi_up",
+				"rpi_info;hash=a_b_c_d_e_f/g+h-i.j",
+				"rpi_bad_name",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.tags), func(t *testing.T) {
+			c := &Client{Prefix: tt.prefix, Tags: tt.tags}
+
+			for i, s := range samples {
+				assert.Equal(t, tt.want[i], c.path(s), s.Name)
+			}
+		})
+	}
+
+	// Graphite does not allow empty tag values.
+	c := &Client{Tags: TagsGraphite}
+	assert.Equal(t, "rpi_info;id=x", c.path(sample("rpi_info", 1, "empty", "", "id", "x")))
+}
+
+func TestSend(t *testing.T) {
+	addr, lines := carbon(t)
+
+	c, err := New(addr, TagsGraphite)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	c.Prefix = "pi"
+	c.Timeout = time.Second
+
+	require.NoError(t, c.Send(context.Background(), []prometheus.Sample{
+		sample("a", 1.5, "id", "x"),
+		sample("nan", math.NaN()),
+		sample("inf", math.Inf(1)),
+		sample("neg_inf", math.Inf(-1)),
+		sample("b", -2),
+		sample("c", 1e21),
+	}, sendTime))
+
+	assert.Equal(t, []string{
+		"pi.a;id=x 1.5 1700000000",
+		"pi.b -2 1700000000",
+		"pi.c 1000000000000000000000 1700000000",
+	}, receive(t, lines, 3))
+
+	// The connection is kept open between sends.
+	conn := c.conn
+
+	require.NoError(t, c.Send(context.Background(), []prometheus.Sample{sample("a", 2, "id", "x")}, sendTime))
+	assert.Equal(t, []string{"pi.a;id=x 2 1700000000"}, receive(t, lines, 1))
+	assert.Same(t, conn, c.conn)
+}
+
+func TestSendReconnects(t *testing.T) {
+	addr, lines := carbon(t)
+
+	c, err := New(addr, TagsPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	require.NoError(t, c.Send(context.Background(), []prometheus.Sample{sample("a", 1)}, sendTime))
+	assert.Equal(t, []string{"a 1 1700000000"}, receive(t, lines, 1))
+
+	// A failed send closes the connection, and the next send connects again.
+	require.NoError(t, c.conn.Close())
+	require.Error(t, c.Send(context.Background(), []prometheus.Sample{sample("b", 2)}, sendTime))
+	assert.Nil(t, c.conn)
+
+	require.NoError(t, c.Send(context.Background(), []prometheus.Sample{sample("c", 3)}, sendTime))
+	assert.Equal(t, []string{"c 3 1700000000"}, receive(t, lines, 1))
+}
+
+func TestSendUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	c, err := New(addr, TagsPath)
+	require.NoError(t, err)
+
+	require.Error(t, c.Send(context.Background(), []prometheus.Sample{sample("a", 1)}, sendTime))
+	assert.Nil(t, c.conn)
+}
@@ -0,0 +1,85 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	contentTypeLineProtocol = "text/plain; charset=utf-8"
+	maxErrorBody            = 512
+)
+
+// Client writes line protocol to a bucket of an InfluxDB v2 server.
+type Client struct {
+	// HTTPClient is the HTTP client used for requests.
+	HTTPClient *http.Client
+	// Token is the API token sent with every request, if set.
+	Token string
+
+	url string
+}
+
+// NewClient returns a client that writes to bucket in org on the InfluxDB server at baseURL. The
+// timestamps of written points must be in nanoseconds, as produced by Render.
+func NewClient(baseURL, org, bucket string) (*Client, error) {
+	if org == "" || bucket == "" {
+		return nil, errors.New("org and bucket must not be empty")
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid influxdb url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid influxdb url %q: scheme must be http or https", baseURL)
+	}
+
+	u = u.JoinPath("api", "v2", "write")
+	u.RawQuery = url.Values{"org": {org}, "bucket": {bucket}, "precision": {"ns"}}.Encode()
+	u.Fragment = ""
+
+	return &Client{HTTPClient: http.DefaultClient, url: u.String()}, nil
+}
+
+// URL returns the URL of the write endpoint.
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Write posts body, in line protocol, to the write endpoint.
+func (c *Client) Write(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentTypeLineProtocol)
+	req.Header.Set("Accept", "application/json")
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to write metrics: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s from influxdb: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package influx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	c, err := NewClient("https://influx.example.com:8086/prefix/#frag", "my org", "rpi/bucket")
+	require.NoError(t, err)
+	assert.Equal(t, "https://influx.example.com:8086/prefix/api/v2/write?bucket=rpi%2Fbucket&org=my+org&precision=ns",
+		c.URL())
+
+	invalid := []struct{ url, org, bucket string }{
+		{"http://localhost:8086", "", "rpi"},
+		{"http://localhost:8086", "org", ""},
+		{"localhost:8086", "org", "rpi"},
+		{"udp://localhost:8089", "org", "rpi"},
+		{"://", "org", "rpi"},
+	}
+
+	for _, tt := range invalid {
+		_, err := NewClient(tt.url, tt.org, tt.bucket)
+		require.Error(t, err, tt.url)
+	}
+}
+
+func TestClientWrite(t *testing.T) {
+	var (
+		req  *http.Request
+		body []byte
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, "org", "rpi")
+	require.NoError(t, err)
+
+	c.Token = "s3cret"
+
+	require.NoError(t, c.Write(context.Background(), []byte("m v=1i 1\n")))
+
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "/api/v2/write", req.URL.Path)
+	assert.Equal(t, "org", req.URL.Query().Get("org"))
+	assert.Equal(t, "rpi", req.URL.Query().Get("bucket"))
+	assert.Equal(t, "ns", req.URL.Query().Get("precision"))
+	assert.Equal(t, "Token s3cret", req.Header.Get("Authorization"))
+	assert.Equal(t, contentTypeLineProtocol, req.Header.Get("Content-Type"))
+	assert.Equal(t, "m v=1i 1\n", string(body))
+
+	// Without a token, no Authorization header is sent.
+	c.Token = ""
+
+	require.NoError(t, c.Write(context.Background(), []byte("m v=1i 1\n")))
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestClientWriteError(t *testing.T) {
+	for _, status := range []int{
+		http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusServiceUnavailable,
+	} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				_, _ = io.WriteString(w, `{"code":"invalid","message":"unable to parse 'm v=': missing field value"}`+"\n")
+			}))
+			defer srv.Close()
+
+			c, err := NewClient(srv.URL, "org", "rpi")
+			require.NoError(t, err)
+
+			err = c.Write(context.Background(), []byte("m v=\n"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), http.StatusText(status))
+			assert.Contains(t, err.Error(), `: {"code":"invalid","message":"unable to parse 'm v=': missing field value"}`)
+		})
+	}
+}
+
+func TestClientWriteUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	c, err := NewClient(srv.URL, "org", "rpi")
+	require.NoError(t, err)
+
+	require.Error(t, c.Write(context.Background(), []byte("m v=1i 1\n")))
+}
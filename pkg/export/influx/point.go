@@ -0,0 +1,135 @@
+package influx
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `, "\n", `\n`)
+	keyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+	stringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`, "\n", `\n`)
+)
+
+type tag struct {
+	key, value string
+}
+
+type field struct {
+	key, value string
+}
+
+// point is a single line of line protocol. Field values are stored encoded.
+type point struct {
+	measurement string
+	tags        []tag
+	fields      []field
+}
+
+func newPoint(measurement string, tags ...tag) *point {
+	return &point{measurement: measurement, tags: tags}
+}
+
+func (p *point) float(key string, v float64) {
+	// Line protocol has no representation of NaN or infinity.
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+
+	p.fields = append(p.fields, field{key, strconv.FormatFloat(v, 'g', -1, 64)})
+}
+
+// float32 stores a reading of the mailbox with the shortest representation at its own precision.
+func (p *point) float32(key string, v float32) {
+	if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+		return
+	}
+
+	p.fields = append(p.fields, field{key, strconv.FormatFloat(float64(v), 'g', -1, 32)})
+}
+
+func (p *point) int(key string, v int64) {
+	p.fields = append(p.fields, field{key, strconv.FormatInt(v, 10) + "i"})
+}
+
+// uint stores an unsigned value as integer field, clamped to the integer range, because unsigned
+// fields are not supported by all InfluxDB versions.
+func (p *point) uint(key string, v uint64) {
+	p.int(key, int64(min(v, math.MaxInt64)))
+}
+
+func (p *point) bool(key string, v bool) {
+	p.fields = append(p.fields, field{key, strconv.FormatBool(v)})
+}
+
+func (p *point) string(key, v string) {
+	p.fields = append(p.fields, field{key, `"` + stringEscaper.Replace(v) + `"`})
+}
+
+// appendTo appends the point to b as a line, with extra tags that the point does not set itself.
+// Points without fields are invalid in line protocol and are skipped.
+func (p *point) appendTo(b []byte, extra map[string]string, t time.Time) []byte {
+	if len(p.fields) == 0 {
+		return b
+	}
+
+	tags := slices.Clone(p.tags)
+
+	for key, value := range extra {
+		if !slices.ContainsFunc(tags, func(t tag) bool { return t.key == key }) {
+			tags = append(tags, tag{key, value})
+		}
+	}
+
+	// Sorted tags are the most efficient for the server to index.
+	slices.SortFunc(tags, func(a, b tag) int { return strings.Compare(a.key, b.key) })
+
+	b = append(b, measurementEscaper.Replace(p.measurement)...)
+
+	for _, t := range tags {
+		// Empty tag values are not allowed.
+		if t.value == "" {
+			continue
+		}
+
+		b = append(b, ',')
+		b = append(b, keyEscaper.Replace(t.key)...)
+		b = append(b, '=')
+		b = append(b, keyEscaper.Replace(t.value)...)
+	}
+
+	for i, f := range p.fields {
+		if i == 0 {
+			b = append(b, ' ')
+		} else {
+			b = append(b, ',')
+		}
+
+		b = append(b, keyEscaper.Replace(f.key)...)
+		b = append(b, '=')
+		b = append(b, f.value...)
+	}
+
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, t.UnixNano(), 10)
+
+	return append(b, '\n')
+}
+
+func writePoints(w io.Writer, points []*point, extra map[string]string, t time.Time) error {
+	var b []byte
+	for _, p := range points {
+		b = p.appendTo(b, extra, t)
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("unable to write metrics: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package influx
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pointTime = time.Unix(1700000000, 123456789)
+
+func line(p *point, extra map[string]string) string {
+	return string(p.appendTo(nil, extra, pointTime))
+}
+
+func TestPointEscaping(t *testing.T) {
+	tests := []struct {
+		name string
+		p    func() *point
+		want string
+	}{
+		{
+			name: "measurement",
+			p: func() *point {
+				p := newPoint("rpi board,a=b\"c\\d\ne")
+				p.int("v", 1)
+
+				return p
+			},
+			want: `rpi\ board\,a=b"c\d\ne v=1i 1700000000123456789` + "\n",
+		},
+		{
+			name: "tag key and value",
+			p: func() *point {
+				p := newPoint("m", tag{"a key,=\"\\\n", "a value,=\"\\\n"})
+				p.int("v", 1)
+
+				return p
+			},
+			want: `m,a\ key\,\="\\n=a\ value\,\="\\n v=1i 1700000000123456789` + "\n",
+		},
+		{
+			name: "field key",
+			p: func() *point {
+				p := newPoint("m")
+				p.int("a key,=\"\\\n", 1)
+
+				return p
+			},
+			want: `m a\ key\,\="\\n=1i 1700000000123456789` + "\n",
+		},
+		{
+			name: "string field",
+			p: func() *point {
+				p := newPoint("m")
+				p.string("s", "a value, = \"quoted\" \\path\nline")
+
+				return p
+			},
+			want: `m s="a value, = \"quoted\" \\path\nline" 1700000000123456789` + "\n",
+		},
+		{
+			name: "empty string field",
+			p: func() *point {
+				p := newPoint("m")
+				p.string("s", "")
+
+				return p
+			},
+			want: `m s="" 1700000000123456789` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, line(tt.p(), nil))
+		})
+	}
+}
+
+func TestPointFields(t *testing.T) {
+	p := newPoint("m")
+	p.float("float", 0.1)
+	p.float("large", 1e21)
+	p.float32("float32", 0.8)
+	p.int("int", -42)
+	p.uint("uint", 42)
+	p.uint("uint_max", math.MaxUint64)
+	p.bool("true", true)
+	p.bool("false", false)
+
+	assert.Equal(t,
+		"m float=0.1,large=1e+21,float32=0.8,int=-42i,uint=42i,uint_max=9223372036854775807i,true=true,false=false "+
+			"1700000000123456789\n",
+		line(p, nil))
+}
+
+func TestPointSkipsNaNAndInf(t *testing.T) {
+	p := newPoint("m")
+	p.float("nan", math.NaN())
+	p.float("inf", math.Inf(1))
+	p.float("neg_inf", math.Inf(-1))
+	p.float32("nan32", float32(math.NaN()))
+	p.float32("inf32", float32(math.Inf(1)))
+
+	// A point without fields is not valid line protocol.
+	assert.Empty(t, line(p, nil))
+
+	p.float("ok", 1)
+	assert.Equal(t, "m ok=1 1700000000123456789\n", line(p, nil))
+}
+
+func TestPointTags(t *testing.T) {
+	p := newPoint("m", tag{"id", "arm"}, tag{"empty", ""})
+	p.int("v", 1)
+
+	// Extra tags are sorted with those of the point, which take precedence. Empty values are left out.
+	got := line(p, map[string]string{"id": "other", "host": "pi", "zone": "", "arch": "arm64"})
+	assert.Equal(t, "m,arch=arm64,host=pi,id=arm v=1i 1700000000123456789\n", got)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWritePoints(t *testing.T) {
+	a, b, empty := newPoint("a"), newPoint("b"), newPoint("empty")
+	a.int("v", 1)
+	b.int("v", 2)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writePoints(&buf, []*point{a, empty, b}, nil, pointTime))
+	assert.Equal(t, "a v=1i 1700000000123456789\nb v=2i 1700000000123456789\n", buf.String())
+
+	require.Error(t, writePoints(failingWriter{}, []*point{a}, nil, pointTime))
+}
@@ -0,0 +1,252 @@
+/*
+Package influx provides an InfluxDB line protocol renderer for the hardware snapshot of a Raspberry
+Pi, and a client for the InfluxDB v2 write API. The line protocol is documented here:
+
+https://docs.influxdata.com/influxdb/v2/reference/syntax/line-protocol/
+*/
+package influx
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+// sectionPoints maps the sections of a snapshot to the function converting them into points.
+var sectionPoints = map[string]func(*snapshot.Snapshot) []*point{
+	snapshot.SectionHardware:    boardPoints,
+	snapshot.SectionPower:       powerPoints,
+	snapshot.SectionClocks:      clockPoints,
+	snapshot.SectionTemperature: temperaturePoints,
+	snapshot.SectionVoltage:     voltagePoints,
+	snapshot.SectionThrottle:    throttlePoints,
+	snapshot.SectionSampler:     samplerPoints,
+	snapshot.SectionExporter:    exporterPoints,
+}
+
+// Write collects a snapshot and writes it as line protocol. Readings that could not be collected
+// are left out and returned as an error after the snapshot has been written.
+func Write(w io.Writer) error {
+	snap, err := snapshot.Collect()
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(Render(w, snap), snap.Err())
+}
+
+// Render writes a snapshot as line protocol, timestamped with the snapshot time in nanoseconds.
+func Render(w io.Writer, snap *snapshot.Snapshot) error {
+	return RenderTags(w, snap, nil)
+}
+
+// RenderTags writes a snapshot as line protocol, adding tags to every point.
+func RenderTags(w io.Writer, snap *snapshot.Snapshot, tags map[string]string) error {
+	var points []*point
+
+	for _, section := range snapshot.Sections {
+		if snap.Has(section) {
+			points = append(points, sectionPoints[section](snap)...)
+		}
+	}
+
+	return writePoints(w, points, tags, snap.Time)
+}
+
+func boardPoints(snap *snapshot.Snapshot) []*point {
+	board := snap.Board
+	p := newPoint("rpi_board")
+
+	if board.FirmwareRevision.OK() {
+		p.int("firmware_revision", int64(board.FirmwareRevision.Value))
+	}
+
+	if board.FirmwareVariant.OK() {
+		p.string("firmware_variant", board.FirmwareVariant.Value)
+	}
+
+	if board.FirmwareHash.OK() {
+		p.string("firmware_hash", board.FirmwareHash.Value)
+	}
+
+	if board.Model.OK() {
+		p.int("model", int64(board.Model.Value))
+	}
+
+	if board.Revision.OK() {
+		p.int("revision", int64(board.Revision.Value))
+	}
+
+	return []*point{p}
+}
+
+func powerPoints(snap *snapshot.Snapshot) []*point {
+	points := make([]*point, 0, len(snap.Power))
+
+	for _, id := range slices.Sorted(maps.Keys(snap.Power)) {
+		p := newPoint("rpi_power", tag{"id", id})
+
+		if state := snap.Power[id]; state.OK() {
+			p.int("state", int64(state.Value))
+		}
+
+		points = append(points, p)
+	}
+
+	return points
+}
+
+func clockPoints(snap *snapshot.Snapshot) []*point {
+	points := make([]*point, 0, len(snap.Clocks)+1)
+
+	for _, id := range slices.Sorted(maps.Keys(snap.Clocks)) {
+		clock := snap.Clocks[id]
+		p := newPoint("rpi_clock", tag{"id", id})
+
+		if clock.RateHz.OK() {
+			p.int("rate_hz", int64(clock.RateHz.Value))
+		}
+
+		if clock.MeasuredHz.OK() {
+			p.int("measured_hz", int64(clock.MeasuredHz.Value))
+		}
+
+		points = append(points, p)
+	}
+
+	turbo := newPoint("rpi_turbo")
+
+	if snap.Throttle.Turbo.OK() {
+		turbo.bool("enabled", snap.Throttle.Turbo.Value)
+	}
+
+	return append(points, turbo)
+}
+
+func temperaturePoints(snap *snapshot.Snapshot) []*point {
+	p := newPoint("rpi_temperature")
+
+	if snap.Temperature.Celsius.OK() {
+		p.float32("celsius", snap.Temperature.Celsius.Value)
+	}
+
+	if snap.Temperature.MaxCelsius.OK() {
+		p.float32("max_celsius", snap.Temperature.MaxCelsius.Value)
+	}
+
+	return []*point{p}
+}
+
+func voltagePoints(snap *snapshot.Snapshot) []*point {
+	points := make([]*point, 0, len(snap.Voltages))
+
+	for _, id := range slices.Sorted(maps.Keys(snap.Voltages)) {
+		voltage := snap.Voltages[id]
+		p := newPoint("rpi_voltage", tag{"id", id})
+
+		if voltage.Volts.OK() {
+			p.float32("volts", voltage.Volts.Value)
+		}
+
+		if voltage.MinVolts.OK() {
+			p.float32("min_volts", voltage.MinVolts.Value)
+		}
+
+		if voltage.MaxVolts.OK() {
+			p.float32("max_volts", voltage.MaxVolts.Value)
+		}
+
+		points = append(points, p)
+	}
+
+	return points
+}
+
+func throttlePoints(snap *snapshot.Snapshot) []*point {
+	p := newPoint("rpi_throttle")
+
+	if state := snap.Throttle.State; state.OK() {
+		p.int("flags", int64(state.Value.Flags))
+
+		for _, cond := range snapshot.ThrottleConditions {
+			p.bool(cond.Name, state.Value.Flags&cond.Active != 0)
+			p.bool(cond.Name+"_occurred", state.Value.Flags&cond.Occurred != 0)
+		}
+	}
+
+	points := []*point{p}
+
+	if events := snap.ThrottleEvents; events != nil {
+		for _, cond := range snapshot.ThrottleConditions {
+			e := newPoint("rpi_throttle_events", tag{"condition", cond.Name})
+			e.uint("events", events.Events[cond.Name])
+			e.float("seconds", events.Seconds[cond.Name])
+			points = append(points, e)
+		}
+	}
+
+	return points
+}
+
+func samplerPoints(snap *snapshot.Snapshot) []*point {
+	samples := snap.Samples
+	if samples == nil {
+		return nil
+	}
+
+	p := newPoint("rpi_sampler")
+	p.uint("samples", samples.Count)
+	p.uint("errors", samples.Errors)
+	p.int("throttled", int64(samples.Throttled))
+
+	points := []*point{p, seriesPoint("rpi_sampled_temperature", samples.Temperature)}
+
+	for _, id := range slices.Sorted(maps.Keys(samples.Clocks)) {
+		points = append(points, seriesPoint("rpi_sampled_clock", samples.Clocks[id], tag{"id", id}))
+	}
+
+	return points
+}
+
+// seriesPoint summarises sampled values. Min and max are left out if nothing was sampled.
+func seriesPoint(measurement string, s snapshot.Series, tags ...tag) *point {
+	p := newPoint(measurement, tags...)
+	p.float("min", s.Min)
+	p.float("max", s.Max)
+	p.uint("count", s.Histogram.Count)
+	p.float("sum", s.Histogram.Sum)
+
+	return p
+}
+
+func exporterPoints(snap *snapshot.Snapshot) []*point {
+	exp := snap.Exporter
+
+	p := newPoint("rpi_exporter")
+	p.string("version", exp.Build.Version)
+	p.string("commit", exp.Build.Commit)
+	p.string("go_version", exp.Build.GoVersion)
+	p.float("scrape_duration_seconds", snap.Duration.Seconds())
+	p.uint("cache_hits", exp.Cache.Hits)
+	p.float("sample_age_seconds", exp.Cache.Age.Seconds())
+	p.uint("mailbox_requests", exp.Mailbox.Count)
+	p.uint("mailbox_request_errors", exp.Mailbox.Errors)
+	p.float("mailbox_request_duration_seconds", exp.Mailbox.Sum)
+	p.int("goroutines", int64(exp.Runtime.Goroutines))
+	p.uint("heap_alloc_bytes", exp.Runtime.HeapAllocBytes)
+	p.uint("sys_bytes", exp.Runtime.SysBytes)
+
+	if proc := exp.Process; proc.OK() {
+		p.float("cpu_seconds", proc.Value.CPUSeconds)
+		p.uint("resident_memory_bytes", proc.Value.ResidentBytes)
+		p.uint("virtual_memory_bytes", proc.Value.VirtualBytes)
+		p.int("open_fds", int64(proc.Value.OpenFDs))
+		p.uint("max_fds", proc.Value.MaxFDs)
+		p.int("start_time_seconds", proc.Value.StartTime.Unix())
+	}
+
+	return []*point{p}
+}
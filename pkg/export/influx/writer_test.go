@@ -0,0 +1,128 @@
+package influx
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+	"github.com/schubergphilis/rpi_exporter/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func reading[T any](v T, t time.Time) snapshot.Reading[T] {
+	return snapshot.Reading[T]{Value: v, Time: t}
+}
+
+// goldenSnapshot returns a snapshot of all sections with fixed readings. The SPI power state and the
+// measured core clock could not be read, so the golden file also covers skipped values.
+func goldenSnapshot() *snapshot.Snapshot {
+	at := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	since := at.Add(-time.Hour)
+	errRead := errors.New("mailbox read failed")
+
+	return &snapshot.Snapshot{
+		Time:     at,
+		Duration: 25 * time.Millisecond,
+		Board: snapshot.Board{
+			FirmwareRevision: reading[uint32](1679047839, at),
+			FirmwareVariant:  reading("start", at),
+			FirmwareHash:     reading("82f3750a\"quoted\\path\nline", at),
+			Model:            reading[uint32](0, at),
+			Revision:         reading[uint32](0xa03111, at),
+			Serial:           reading("10000000deadbeef", at),
+		},
+		Clocks: map[string]snapshot.Clock{
+			"arm":  {RateHz: reading(1500000000, at), MeasuredHz: reading(1500398464, at)},
+			"core": {RateHz: reading(500000000, at), MeasuredHz: snapshot.Reading[int]{Time: at, Err: errRead}},
+		},
+		Voltages: map[string]snapshot.Voltage{
+			"core": {
+				Volts:    reading[float32](0.86, at),
+				MinVolts: reading[float32](0.8, at),
+				MaxVolts: reading[float32](1.2, at),
+			},
+		},
+		Temperature: snapshot.Temperature{Celsius: reading[float32](48.312, at), MaxCelsius: reading[float32](85, at)},
+		Power: map[string]snapshot.Reading[uint32]{
+			"sd_card": reading[uint32](1, at),
+			"spi":     {Time: at, Err: errRead},
+		},
+		Throttle: snapshot.Throttle{
+			State: reading(snapshot.NewThrottleState(0x50005), at),
+			Turbo: reading(true, at),
+		},
+		Exporter: snapshot.Exporter{
+			Build:   version.Info{Version: "v1.2.3", Commit: "abc123", GoVersion: "go1.24.4"},
+			Mailbox: mbox.IoctlStats{Since: since, Count: 120, Errors: 2, Sum: 0.0625},
+			Process: reading(snapshot.Process{
+				CPUSeconds:    12.5,
+				ResidentBytes: 12 << 20,
+				VirtualBytes:  700 << 20,
+				OpenFDs:       9,
+				MaxFDs:        1024,
+				StartTime:     since,
+			}, at),
+			Runtime: snapshot.Runtime{Goroutines: 12, HeapAllocBytes: 3 << 20, SysBytes: 16 << 20},
+			Cache:   snapshot.CacheStats{Hits: 4, Age: 1500 * time.Millisecond},
+		},
+		Samples: &snapshot.Samples{
+			Since:    since,
+			Interval: time.Second,
+			Count:    3600,
+			Errors:   1,
+			Temperature: snapshot.Series{
+				Histogram: snapshot.Histogram{Count: 3600, Sum: 172800},
+				Min:       46.2,
+				Max:       51.6,
+			},
+			Clocks: map[string]snapshot.Series{
+				// Nothing was sampled since the last drain.
+				"arm": {Histogram: snapshot.Histogram{Count: 10, Sum: 15e9}, Min: math.NaN(), Max: math.NaN()},
+			},
+			Throttled: 0x4,
+		},
+		ThrottleEvents: &snapshot.ThrottleEvents{
+			Since:   since,
+			Events:  map[string]uint64{"under_voltage": 3, "throttled": 1},
+			Seconds: map[string]float64{"under_voltage": 12.5, "throttled": 2},
+		},
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, RenderTags(&buf, goldenSnapshot(), map[string]string{"host": "pi", "rack": "a 1"}))
+
+	path := filepath.Join("testdata", "snapshot.lp")
+	if *update {
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), buf.String())
+}
+
+func TestRenderFiltered(t *testing.T) {
+	filter, err := snapshot.NewFilter(snapshot.SectionTemperature)
+	require.NoError(t, err)
+
+	snap := goldenSnapshot()
+	snap.Filter = filter
+
+	var buf bytes.Buffer
+
+	require.NoError(t, Render(&buf, snap))
+	assert.Equal(t, "rpi_temperature celsius=48.312,max_celsius=85 1792324800000000000\n", buf.String())
+}
@@ -0,0 +1,68 @@
+/*
+Package json provides a JSON renderer for the hardware snapshot of a Raspberry Pi.
+*/
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+// sectionKeys maps the sections of a snapshot to the JSON keys they populate.
+var sectionKeys = map[string][]string{
+	snapshot.SectionHardware:    {"board"},
+	snapshot.SectionPower:       {"power"},
+	snapshot.SectionClocks:      {"clocks"},
+	snapshot.SectionTemperature: {"temperature"},
+	snapshot.SectionVoltage:     {"voltages"},
+	snapshot.SectionThrottle:    {"throttle", "throttle_events"},
+	snapshot.SectionSampler:     {"samples"},
+	snapshot.SectionExporter:    {"exporter"},
+}
+
+// Write collects a snapshot and writes it as indented JSON. Readings that could not be collected
+// are included with their error and returned as an error after the snapshot has been written.
+func Write(w io.Writer) error {
+	snap, err := snapshot.Collect()
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(Render(w, snap), snap.Err())
+}
+
+// Render writes a snapshot as indented JSON, omitting the sections it does not include.
+func Render(w io.Writer, snap *snapshot.Snapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("unable to encode snapshot: %w", err)
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return fmt.Errorf("unable to encode snapshot: %w", err)
+	}
+
+	for section, keys := range sectionKeys {
+		if snap.Has(section) {
+			continue
+		}
+
+		for _, key := range keys {
+			delete(obj, key)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(obj); err != nil {
+		return fmt.Errorf("unable to encode snapshot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,304 @@
+/*
+Package mqtt publishes the hardware snapshot of a Raspberry Pi to an MQTT broker as retained JSON
+messages, along with Home Assistant discovery configs. It includes a minimal MQTT 3.1.1 client that
+only publishes with QoS 0. The protocol is documented here:
+
+https://docs.oasis-open.org/mqtt/mqtt/v3.1.1/mqtt-v3.1.1.html
+*/
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPort    = "1883"
+	defaultTLSPort = "8883"
+
+	// keepAliveGrace is how much longer than the keep alive interval the client waits for any packet
+	// from the broker before it considers the connection lost.
+	keepAliveGrace = 3 / 2.0
+	writeTimeout   = 10 * time.Second
+)
+
+// errClosed is recorded when the connection is closed by Close.
+var errClosed = errors.New("connection closed")
+
+// Message is an application message.
+type Message struct {
+	Topic   string
+	Payload []byte
+	// Retain asks the broker to keep the message and send it to future subscribers of the topic.
+	Retain bool
+}
+
+// Options configures a connection to a broker.
+type Options struct {
+	ClientID string
+	Username string
+	// Password is only sent along with a Username.
+	Password string
+	// KeepAlive is the interval of keep alive pings. Zero disables them.
+	KeepAlive time.Duration
+	// Will is published by the broker if the connection is lost without a disconnect.
+	Will *Message
+	// TLSConfig is used for ssl://, tls:// and mqtts:// brokers.
+	TLSConfig *tls.Config
+}
+
+// Client is a connection to an MQTT 3.1.1 broker that publishes messages with QoS 0.
+type Client struct {
+	conn      net.Conn
+	keepAlive time.Duration
+
+	mu   sync.Mutex
+	err  error
+	done chan struct{}
+	once sync.Once
+}
+
+// Dial connects to the broker at brokerURL, for example tcp://localhost:1883 or
+// mqtts://broker.example.com.
+func Dial(ctx context.Context, brokerURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(brokerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker url: %w", err)
+	}
+
+	var dialer interface {
+		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	}
+
+	port := defaultPort
+
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		dialer = &net.Dialer{}
+	case "ssl", "tls", "mqtts":
+		dialer = &tls.Dialer{Config: opts.TLSConfig}
+		port = defaultTLSPort
+	default:
+		return nil, fmt.Errorf("invalid broker url %q: scheme must be tcp, mqtt, ssl, tls or mqtts", brokerURL)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to broker: %w", err)
+	}
+
+	c := &Client{conn: conn, keepAlive: opts.KeepAlive, done: make(chan struct{})}
+	r := bufio.NewReader(conn)
+
+	if err := c.connect(ctx, r, opts); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	go c.read(r)
+
+	if c.keepAlive > 0 {
+		go c.ping()
+	}
+
+	return c, nil
+}
+
+func (c *Client) connect(ctx context.Context, r *bufio.Reader, opts Options) error {
+	strs := map[string]string{"client id": opts.ClientID, "username": opts.Username, "password": opts.Password}
+	if opts.Will != nil {
+		strs["will topic"] = opts.Will.Topic
+		strs["will message"] = string(opts.Will.Payload)
+	}
+
+	for name, s := range strs {
+		if err := checkString(name, s); err != nil {
+			return err
+		}
+	}
+
+	flags := byte(connectCleanSession)
+
+	payload := packet{}.string(opts.ClientID)
+
+	if opts.Will != nil {
+		flags |= connectWill
+		if opts.Will.Retain {
+			flags |= connectWillRetain
+		}
+
+		payload = payload.string(opts.Will.Topic).uint16(uint16(len(opts.Will.Payload))).raw(opts.Will.Payload)
+	}
+
+	if opts.Username != "" {
+		flags |= connectUsername
+		payload = payload.string(opts.Username)
+
+		if opts.Password != "" {
+			flags |= connectPassword
+			payload = payload.string(opts.Password)
+		}
+	}
+
+	p := packet{}.string(protocolName).byte(protocolLevel).byte(flags).
+		uint16(uint16(opts.KeepAlive / time.Second)).raw(payload)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("unable to set deadline: %w", err)
+		}
+
+		defer c.conn.SetDeadline(time.Time{})
+	}
+
+	if err := c.write(packetConnect, p); err != nil {
+		return err
+	}
+
+	header, body, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+
+	if header != packetConnack || len(body) != 2 {
+		return fmt.Errorf("unexpected packet 0x%02x from broker, expected connack", header)
+	}
+
+	if code := body[1]; code != 0 {
+		if msg, ok := connackReturnCodes[code]; ok {
+			return fmt.Errorf("connection refused: %s", msg)
+		}
+
+		return fmt.Errorf("connection refused with code %d", code)
+	}
+
+	return nil
+}
+
+// read consumes packets from the broker until the connection fails. A QoS 0 publisher only
+// receives ping responses, which prove the connection is alive.
+func (c *Client) read(r *bufio.Reader) {
+	for {
+		if c.keepAlive > 0 {
+			deadline := time.Now().Add(time.Duration(float64(c.keepAlive) * keepAliveGrace))
+			if err := c.conn.SetReadDeadline(deadline); err != nil {
+				c.fail(fmt.Errorf("unable to set deadline: %w", err))
+
+				return
+			}
+		}
+
+		if _, _, err := readPacket(r); err != nil {
+			c.fail(fmt.Errorf("connection lost: %w", err))
+
+			return
+		}
+	}
+}
+
+func (c *Client) ping() {
+	ticker := time.NewTicker(c.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.write(packetPingreq, nil); err != nil {
+				c.fail(err)
+
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) write(header byte, p packet) error {
+	b, err := p.encode(header)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return fmt.Errorf("unable to set deadline: %w", err)
+	}
+
+	if _, err := c.conn.Write(b); err != nil {
+		return fmt.Errorf("unable to write packet: %w", err)
+	}
+
+	return nil
+}
+
+// fail records the first error of the connection and closes it.
+func (c *Client) fail(err error) {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+
+		c.conn.Close()
+		close(c.done)
+	})
+}
+
+// Publish publishes a message with QoS 0.
+func (c *Client) Publish(msg Message) error {
+	if err := checkString("topic", msg.Topic); err != nil {
+		return err
+	}
+
+	header := byte(packetPublish)
+	if msg.Retain {
+		header |= publishRetain
+	}
+
+	if err := c.write(header, packet{}.string(msg.Topic).raw(msg.Payload)); err != nil {
+		return fmt.Errorf("unable to publish to %s: %w", msg.Topic, err)
+	}
+
+	return nil
+}
+
+// Done returns a channel that is closed when the connection is lost or closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the connection was lost, or nil while it is up.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// Close disconnects from the broker. The will message is discarded by the broker.
+func (c *Client) Close() error {
+	err := c.write(packetDisconnect, nil)
+
+	c.fail(errClosed)
+
+	return err
+}
@@ -0,0 +1,274 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = 5 * time.Second
+
+// connectPacket is a decoded CONNECT packet.
+type connectPacket struct {
+	protocol  string
+	level     byte
+	flags     byte
+	keepAlive uint16
+	clientID  string
+	willTopic string
+	will      string
+	username  string
+	password  string
+}
+
+// received is a packet received by the broker stand-in.
+type received struct {
+	header byte
+	// topic and payload are only set for PUBLISH packets.
+	topic   string
+	payload string
+}
+
+// broker is a stand-in for an MQTT broker that accepts a single connection, answers the CONNECT
+// with returnCode and records all packets that follow.
+type broker struct {
+	addr    string
+	connect chan connectPacket
+	packets chan received
+}
+
+func newBroker(t *testing.T, returnCode byte) *broker {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { l.Close() })
+
+	b := &broker{
+		addr:    "tcp://" + l.Addr().String(),
+		connect: make(chan connectPacket, 1),
+		packets: make(chan received, 64),
+	}
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		header, body, err := readPacket(r)
+		if err != nil || header != packetConnect {
+			return
+		}
+
+		b.connect <- decodeConnect(body)
+
+		if _, err := conn.Write([]byte{packetConnack, 2, 0, returnCode}); err != nil || returnCode != 0 {
+			return
+		}
+
+		for {
+			header, body, err := readPacket(r)
+			if err != nil {
+				close(b.packets)
+
+				return
+			}
+
+			p := received{header: header}
+			if header&0xf0 == packetPublish {
+				n := binary.BigEndian.Uint16(body)
+				p.topic, p.payload = string(body[2:2+n]), string(body[2+n:])
+			}
+
+			b.packets <- p
+
+			if header == packetPingreq {
+				_, _ = conn.Write([]byte{packetPingresp, 0})
+			}
+		}
+	}()
+
+	return b
+}
+
+func decodeConnect(body []byte) connectPacket {
+	str := func() string {
+		n := binary.BigEndian.Uint16(body)
+		s := string(body[2 : 2+n])
+		body = body[2+n:]
+
+		return s
+	}
+
+	var p connectPacket
+
+	p.protocol = str()
+	p.level, p.flags = body[0], body[1]
+	p.keepAlive = binary.BigEndian.Uint16(body[2:])
+	body = body[4:]
+	p.clientID = str()
+
+	if p.flags&connectWill != 0 {
+		p.willTopic, p.will = str(), str()
+	}
+
+	if p.flags&connectUsername != 0 {
+		p.username = str()
+	}
+
+	if p.flags&connectPassword != 0 {
+		p.password = str()
+	}
+
+	return p
+}
+
+func (b *broker) next(t *testing.T) received {
+	t.Helper()
+
+	select {
+	case p, ok := <-b.packets:
+		require.True(t, ok, "connection closed")
+
+		return p
+	case <-time.After(testTimeout):
+		require.FailNow(t, "timed out waiting for packet")
+	}
+
+	return received{}
+}
+
+func dialTest(t *testing.T, b *broker, opts Options) *Client {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	c, err := Dial(ctx, b.addr, opts)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestConnectWithWill(t *testing.T) {
+	b := newBroker(t, 0)
+
+	c := dialTest(t, b, Options{
+		ClientID:  "rpi_exporter_pi",
+		Username:  "pi",
+		Password:  "s3cret",
+		KeepAlive: time.Minute,
+		Will:      &Message{Topic: "rpi_exporter/pi/availability", Payload: []byte(PayloadOffline), Retain: true},
+	})
+	defer c.Close()
+
+	assert.Equal(t, connectPacket{
+		protocol:  "MQTT",
+		level:     protocolLevel,
+		flags:     connectCleanSession | connectWill | connectWillRetain | connectUsername | connectPassword,
+		keepAlive: 60,
+		clientID:  "rpi_exporter_pi",
+		willTopic: "rpi_exporter/pi/availability",
+		will:      PayloadOffline,
+		username:  "pi",
+		password:  "s3cret",
+	}, <-b.connect)
+}
+
+func TestConnectRefused(t *testing.T) {
+	b := newBroker(t, 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	_, err := Dial(ctx, b.addr, Options{ClientID: "rpi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad user name or password")
+}
+
+func TestPublishAndDisconnect(t *testing.T) {
+	b := newBroker(t, 0)
+	c := dialTest(t, b, Options{ClientID: "rpi"})
+
+	<-b.connect
+
+	require.NoError(t, c.Publish(Message{Topic: "a/b", Payload: []byte("retained"), Retain: true}))
+	require.NoError(t, c.Publish(Message{Topic: "a/c", Payload: []byte("plain")}))
+	require.NoError(t, c.Close())
+
+	assert.Equal(t, received{header: packetPublish | publishRetain, topic: "a/b", payload: "retained"}, b.next(t))
+	assert.Equal(t, received{header: packetPublish, topic: "a/c", payload: "plain"}, b.next(t))
+	assert.Equal(t, received{header: packetDisconnect}, b.next(t))
+
+	<-c.Done()
+	require.ErrorIs(t, c.Err(), errClosed)
+	require.Error(t, c.Publish(Message{Topic: "a/b"}))
+}
+
+func TestPublishLargePayload(t *testing.T) {
+	b := newBroker(t, 0)
+	c := dialTest(t, b, Options{ClientID: "rpi"})
+
+	defer c.Close()
+
+	<-b.connect
+
+	// Large enough for a remaining length of three bytes.
+	payload := make([]byte, 20_000)
+	for i := range payload {
+		payload[i] = byte('a' + i%26)
+	}
+
+	require.NoError(t, c.Publish(Message{Topic: "big", Payload: payload}))
+	assert.Equal(t, received{header: packetPublish, topic: "big", payload: string(payload)}, b.next(t))
+}
+
+func TestConnectionLost(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		_, _, _ = readPacket(bufio.NewReader(conn))
+		_, _ = conn.Write([]byte{packetConnack, 2, 0, 0})
+		conn.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	c, err := Dial(ctx, "tcp://"+l.Addr().String(), Options{ClientID: "rpi"})
+	require.NoError(t, err)
+
+	select {
+	case <-c.Done():
+	case <-time.After(testTimeout):
+		require.FailNow(t, "connection loss not detected")
+	}
+
+	require.Error(t, c.Err())
+	assert.Contains(t, c.Err().Error(), "connection lost")
+}
+
+func TestDialInvalidURL(t *testing.T) {
+	_, err := Dial(context.Background(), "http://broker", Options{})
+	require.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package mqtt
+
+// Home Assistant MQTT discovery is documented here:
+//
+// https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+const (
+	componentSensor       = "sensor"
+	componentBinarySensor = "binary_sensor"
+
+	// payloadNone is rendered by a value template for a reading missing from the state, which Home
+	// Assistant shows as unknown.
+	payloadNone = "None"
+)
+
+// defaultClocks are the clocks whose sensors are enabled by default. The others are created
+// disabled, so they can be enabled in Home Assistant without cluttering every dashboard.
+var defaultClocks = []string{"arm", "core"}
+
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+}
+
+// discoveryConfig is the config of a sensor or binary sensor.
+type discoveryConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	ObjectID          string          `json:"object_id"`
+	StateTopic        string          `json:"state_topic"`
+	ValueTemplate     string          `json:"value_template"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	DeviceClass       string          `json:"device_class,omitempty"`
+	StateClass        string          `json:"state_class,omitempty"`
+	Unit              string          `json:"unit_of_measurement,omitempty"`
+	EntityCategory    string          `json:"entity_category,omitempty"`
+	EnabledByDefault  *bool           `json:"enabled_by_default,omitempty"`
+	Device            discoveryDevice `json:"device"`
+
+	component string
+}
+
+// valueTemplate returns a template extracting the value at path from the state, as readings that
+// could not be collected are left out of it.
+func valueTemplate(path string) string {
+	return fmt.Sprintf("{{ value_json.%s if value_json.%s is defined else '%s' }}", path, path, payloadNone)
+}
+
+// binaryValueTemplate returns a template mapping the boolean at path in the state to ON or OFF.
+func binaryValueTemplate(path string) string {
+	return fmt.Sprintf("{{ ('ON' if value_json.%s else 'OFF') if value_json.%s is defined else '%s' }}",
+		path, path, payloadNone)
+}
+
+// Discovery returns a retained Home Assistant discovery config for every sensor published by State,
+// or nothing if no discovery prefix is set.
+func (t Topics) Discovery(snap *snapshot.Snapshot) ([]Message, error) {
+	if t.DiscoveryPrefix == "" {
+		return nil, nil
+	}
+
+	device := discoveryDevice{
+		Identifiers:  []string{"rpi_exporter_" + t.NodeID},
+		Name:         t.NodeID,
+		Manufacturer: manufacturer,
+		SWVersion:    t.Version,
+	}
+
+	if snap.Has(snapshot.SectionHardware) && snap.Board.Revision.OK() {
+		device.Model = fmt.Sprintf("Revision %06x", snap.Board.Revision.Value)
+	}
+
+	var configs []discoveryConfig
+
+	if snap.Has(snapshot.SectionTemperature) {
+		configs = append(configs, discoveryConfig{
+			Name: "Temperature", ObjectID: "temperature", StateTopic: t.topic("temperature"),
+			ValueTemplate: valueTemplate("celsius"), DeviceClass: "temperature", StateClass: "measurement",
+			Unit: "°C", component: componentSensor,
+		})
+	}
+
+	if snap.Has(snapshot.SectionClocks) {
+		for _, id := range slices.Sorted(maps.Keys(snap.Clocks)) {
+			c := discoveryConfig{
+				Name: id + " clock", ObjectID: id + "_clock", StateTopic: t.topic("clocks"),
+				ValueTemplate: valueTemplate(id + ".measured_hz"), DeviceClass: "frequency",
+				StateClass: "measurement", Unit: "Hz", EntityCategory: "diagnostic", component: componentSensor,
+			}
+
+			if !slices.Contains(defaultClocks, id) {
+				c.EnabledByDefault = new(bool)
+			}
+
+			configs = append(configs, c)
+		}
+	}
+
+	if snap.Has(snapshot.SectionVoltage) {
+		for _, id := range slices.Sorted(maps.Keys(snap.Voltages)) {
+			configs = append(configs, discoveryConfig{
+				Name: id + " voltage", ObjectID: id + "_voltage", StateTopic: t.topic("voltages"),
+				ValueTemplate: valueTemplate(id + ".volts"), DeviceClass: "voltage",
+				StateClass: "measurement", Unit: "V", EntityCategory: "diagnostic", component: componentSensor,
+			})
+		}
+	}
+
+	if snap.Has(snapshot.SectionThrottle) {
+		for _, cond := range snapshot.ThrottleConditions {
+			configs = append(configs, discoveryConfig{
+				Name: strings.ReplaceAll(cond.Name, "_", " "), ObjectID: cond.Name, StateTopic: t.topic("throttle"),
+				ValueTemplate: binaryValueTemplate(cond.Name),
+				DeviceClass:   "problem", component: componentBinarySensor,
+			})
+		}
+	}
+
+	payloads := make(map[string]any, len(configs))
+
+	for _, c := range configs {
+		c.UniqueID = t.NodeID + "_" + c.ObjectID
+		c.AvailabilityTopic = t.Availability()
+		c.Device = device
+		payloads[c.component+"/"+c.ObjectID] = c
+	}
+
+	return retained(payloads, func(name string) string {
+		component, object, _ := strings.Cut(name, "/")
+
+		return t.DiscoveryPrefix + "/" + component + "/" + t.NodeID + "/" + object + "/config"
+	})
+}
@@ -0,0 +1,169 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+const (
+	// PayloadOnline and PayloadOffline are published to the availability topic.
+	PayloadOnline  = "online"
+	PayloadOffline = "offline"
+
+	manufacturer = "Raspberry Pi"
+)
+
+var invalidNodeIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// NodeID converts a name such as a hostname into an identifier valid in topics and Home Assistant
+// discovery.
+func NodeID(name string) string {
+	return invalidNodeIDChars.ReplaceAllString(name, "_")
+}
+
+// Topics builds the messages published for a single Pi.
+type Topics struct {
+	// Base is the prefix of the state and availability topics.
+	Base string
+	// DiscoveryPrefix is the Home Assistant discovery prefix. Discovery messages are only built if
+	// set.
+	DiscoveryPrefix string
+	// NodeID identifies the Pi in topics and discovery configs.
+	NodeID string
+	// Version is reported as software version of the device.
+	Version string
+}
+
+// Availability returns the topic the online and offline payloads are published to.
+func (t Topics) Availability() string {
+	return t.topic("availability")
+}
+
+func (t Topics) topic(name string) string {
+	return t.Base + "/" + t.NodeID + "/" + name
+}
+
+// State returns a retained JSON message for each section of the snapshot. Readings that could not
+// be collected are left out.
+func (t Topics) State(snap *snapshot.Snapshot) ([]Message, error) {
+	states := map[string]any{}
+
+	if snap.Has(snapshot.SectionTemperature) {
+		states["temperature"] = temperatureState(snap.Temperature)
+	}
+
+	if snap.Has(snapshot.SectionClocks) {
+		states["clocks"] = clocksState(snap.Clocks)
+	}
+
+	if snap.Has(snapshot.SectionVoltage) {
+		states["voltages"] = voltagesState(snap.Voltages)
+	}
+
+	if snap.Has(snapshot.SectionThrottle) {
+		states["throttle"] = throttleState(snap.Throttle, snap.Has(snapshot.SectionClocks))
+	}
+
+	return retained(states, t.topic)
+}
+
+// retained encodes payloads as retained JSON messages to the topics returned by topic.
+func retained(payloads map[string]any, topic func(string) string) ([]Message, error) {
+	msgs := make([]Message, 0, len(payloads))
+
+	for name, payload := range payloads {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode %s: %w", name, err)
+		}
+
+		msgs = append(msgs, Message{Topic: topic(name), Payload: b, Retain: true})
+	}
+
+	slices.SortFunc(msgs, func(a, b Message) int { return strings.Compare(a.Topic, b.Topic) })
+
+	return msgs, nil
+}
+
+func temperatureState(temp snapshot.Temperature) map[string]any {
+	state := map[string]any{}
+
+	if temp.Celsius.OK() {
+		state["celsius"] = temp.Celsius.Value
+	}
+
+	if temp.MaxCelsius.OK() {
+		state["max_celsius"] = temp.MaxCelsius.Value
+	}
+
+	return state
+}
+
+func clocksState(clocks map[string]snapshot.Clock) map[string]any {
+	state := make(map[string]any, len(clocks))
+
+	for id, clock := range clocks {
+		s := map[string]any{}
+
+		if clock.RateHz.OK() {
+			s["rate_hz"] = clock.RateHz.Value
+		}
+
+		if clock.MeasuredHz.OK() {
+			s["measured_hz"] = clock.MeasuredHz.Value
+		}
+
+		state[id] = s
+	}
+
+	return state
+}
+
+func voltagesState(voltages map[string]snapshot.Voltage) map[string]any {
+	state := make(map[string]any, len(voltages))
+
+	for id, voltage := range voltages {
+		s := map[string]any{}
+
+		if voltage.Volts.OK() {
+			s["volts"] = voltage.Volts.Value
+		}
+
+		if voltage.MinVolts.OK() {
+			s["min_volts"] = voltage.MinVolts.Value
+		}
+
+		if voltage.MaxVolts.OK() {
+			s["max_volts"] = voltage.MaxVolts.Value
+		}
+
+		state[id] = s
+	}
+
+	return state
+}
+
+// throttleState includes the turbo state only with the clocks section, which collects it.
+func throttleState(throttle snapshot.Throttle, turbo bool) map[string]any {
+	state := map[string]any{}
+
+	if throttle.State.OK() {
+		state["flags"] = throttle.State.Value.Flags
+
+		for _, cond := range snapshot.ThrottleConditions {
+			state[cond.Name] = throttle.State.Value.Flags&cond.Active != 0
+			state[cond.Name+"_occurred"] = throttle.State.Value.Flags&cond.Occurred != 0
+		}
+	}
+
+	if turbo && throttle.Turbo.OK() {
+		state["turbo"] = throttle.Turbo.Value
+	}
+
+	return state
+}
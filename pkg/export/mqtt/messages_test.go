@@ -0,0 +1,158 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testTopics = Topics{Base: "rpi_exporter", DiscoveryPrefix: "homeassistant", NodeID: "pi", Version: "1.0.0"}
+
+	errUnavailable = errors.New("unavailable")
+)
+
+func testSnapshot(t *testing.T) *snapshot.Snapshot {
+	t.Helper()
+
+	filter, err := snapshot.NewFilter(
+		snapshot.SectionTemperature, snapshot.SectionClocks, snapshot.SectionVoltage, snapshot.SectionThrottle,
+	)
+	require.NoError(t, err)
+
+	return &snapshot.Snapshot{
+		Filter: filter,
+		Temperature: snapshot.Temperature{
+			Celsius:    snapshot.Reading[float32]{Value: 48.5},
+			MaxCelsius: snapshot.Reading[float32]{Err: errUnavailable},
+		},
+		Clocks: map[string]snapshot.Clock{
+			"arm": {RateHz: snapshot.Reading[int]{Value: 1500000000}, MeasuredHz: snapshot.Reading[int]{Value: 1499000000}},
+		},
+		Voltages: map[string]snapshot.Voltage{
+			"core": {Volts: snapshot.Reading[float32]{Value: 0.875}, MinVolts: snapshot.Reading[float32]{Value: 0.8},
+				MaxVolts: snapshot.Reading[float32]{Value: 1.2}},
+		},
+		Throttle: snapshot.Throttle{
+			State: snapshot.Reading[snapshot.ThrottleState]{Err: errUnavailable},
+			Turbo: snapshot.Reading[bool]{Value: true},
+		},
+	}
+}
+
+func payloads(msgs []Message) map[string]string {
+	out := make(map[string]string, len(msgs))
+
+	for _, msg := range msgs {
+		out[msg.Topic] = string(msg.Payload)
+	}
+
+	return out
+}
+
+func TestState(t *testing.T) {
+	msgs, err := testTopics.State(testSnapshot(t))
+	require.NoError(t, err)
+
+	for _, msg := range msgs {
+		assert.True(t, msg.Retain, msg.Topic)
+	}
+
+	// Failed readings are left out, rather than published as zero values.
+	assert.Equal(t, map[string]string{
+		"rpi_exporter/pi/clocks":      `{"arm":{"measured_hz":1499000000,"rate_hz":1500000000}}`,
+		"rpi_exporter/pi/temperature": `{"celsius":48.5}`,
+		"rpi_exporter/pi/throttle":    `{"turbo":true}`,
+		"rpi_exporter/pi/voltages":    `{"core":{"max_volts":1.2,"min_volts":0.8,"volts":0.875}}`,
+	}, payloads(msgs))
+}
+
+func TestDiscovery(t *testing.T) {
+	msgs, err := testTopics.Discovery(testSnapshot(t))
+	require.NoError(t, err)
+
+	configs := map[string]map[string]any{}
+
+	for _, msg := range msgs {
+		assert.True(t, msg.Retain, msg.Topic)
+
+		var c map[string]any
+		require.NoError(t, json.Unmarshal(msg.Payload, &c))
+
+		assert.Equal(t, "rpi_exporter/pi/availability", c["availability_topic"])
+		configs[msg.Topic] = c
+	}
+
+	assert.ElementsMatch(t, []string{
+		"homeassistant/binary_sensor/pi/freq_capped/config",
+		"homeassistant/binary_sensor/pi/soft_temp_limit/config",
+		"homeassistant/binary_sensor/pi/throttled/config",
+		"homeassistant/binary_sensor/pi/under_voltage/config",
+		"homeassistant/sensor/pi/arm_clock/config",
+		"homeassistant/sensor/pi/core_voltage/config",
+		"homeassistant/sensor/pi/temperature/config",
+	}, slices.Collect(maps.Keys(configs)))
+
+	temp := configs["homeassistant/sensor/pi/temperature/config"]
+	assert.Equal(t, "pi_temperature", temp["unique_id"])
+	assert.Equal(t, "rpi_exporter/pi/temperature", temp["state_topic"])
+	assert.Equal(t, "{{ value_json.celsius if value_json.celsius is defined else 'None' }}", temp["value_template"])
+
+	clock := configs["homeassistant/sensor/pi/arm_clock/config"]
+	assert.Equal(t, "{{ value_json.arm.measured_hz if value_json.arm.measured_hz is defined else 'None' }}",
+		clock["value_template"])
+
+	// The throttle flags are missing from the state if they could not be read.
+	uv := configs["homeassistant/binary_sensor/pi/under_voltage/config"]
+	assert.Equal(t,
+		"{{ ('ON' if value_json.under_voltage else 'OFF') if value_json.under_voltage is defined else 'None' }}",
+		uv["value_template"])
+}
+
+func TestDiscoveryDisabled(t *testing.T) {
+	topics := testTopics
+	topics.DiscoveryPrefix = ""
+
+	msgs, err := topics.Discovery(testSnapshot(t))
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+}
+
+func TestPublishDiscoveryAndState(t *testing.T) {
+	b := newBroker(t, 0)
+	c := dialTest(t, b, Options{
+		ClientID: "rpi_exporter_pi",
+		Will:     &Message{Topic: testTopics.Availability(), Payload: []byte(PayloadOffline), Retain: true},
+	})
+
+	defer c.Close()
+
+	conn := <-b.connect
+	assert.Equal(t, "rpi_exporter/pi/availability", conn.willTopic)
+	assert.Equal(t, PayloadOffline, conn.will)
+	assert.NotZero(t, conn.flags&connectWillRetain)
+
+	snap := testSnapshot(t)
+
+	discovery, err := testTopics.Discovery(snap)
+	require.NoError(t, err)
+
+	state, err := testTopics.State(snap)
+	require.NoError(t, err)
+
+	msgs := append(discovery, state...)
+	for _, msg := range msgs {
+		require.NoError(t, c.Publish(msg))
+	}
+
+	for _, msg := range msgs {
+		assert.Equal(t, received{header: packetPublish | publishRetain, topic: msg.Topic, payload: string(msg.Payload)},
+			b.next(t))
+	}
+}
@@ -0,0 +1,140 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Control packet types, shifted into the high nibble of the fixed header.
+const (
+	packetConnect    = 1 << 4
+	packetConnack    = 2 << 4
+	packetPublish    = 3 << 4
+	packetPingreq    = 12 << 4
+	packetPingresp   = 13 << 4
+	packetDisconnect = 14 << 4
+)
+
+// Connect flags.
+const (
+	connectCleanSession = 1 << 1
+	connectWill         = 1 << 2
+	connectWillRetain   = 1 << 5
+	connectPassword     = 1 << 6
+	connectUsername     = 1 << 7
+)
+
+const (
+	protocolName  = "MQTT"
+	protocolLevel = 4 // MQTT 3.1.1.
+
+	publishRetain = 1
+
+	maxRemainingLength = 268435455
+	maxStringLength    = 1<<16 - 1
+	remainingLenBits   = 7
+	remainingLenMore   = 0x80
+	maxRemainingBytes  = 4
+)
+
+// connackReturnCodes describes the refusals a broker may answer a connect with.
+var connackReturnCodes = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// packet builds the variable header and payload of a control packet.
+type packet []byte
+
+func (p packet) byte(b byte) packet {
+	return append(p, b)
+}
+
+func (p packet) uint16(v uint16) packet {
+	return binary.BigEndian.AppendUint16(p, v)
+}
+
+func (p packet) string(s string) packet {
+	return p.uint16(uint16(len(s))).raw([]byte(s))
+}
+
+func (p packet) raw(b []byte) packet {
+	return append(p, b...)
+}
+
+// encode prefixes the packet with its fixed header.
+func (p packet) encode(header byte) ([]byte, error) {
+	if len(p) > maxRemainingLength {
+		return nil, fmt.Errorf("packet of %d bytes exceeds the maximum size", len(p))
+	}
+
+	out := []byte{header}
+
+	n := len(p)
+
+	for {
+		b := byte(n & (remainingLenMore - 1))
+		n >>= remainingLenBits
+
+		if n > 0 {
+			b |= remainingLenMore
+		}
+
+		out = append(out, b)
+
+		if n == 0 {
+			break
+		}
+	}
+
+	return append(out, p...), nil
+}
+
+// checkString reports whether s fits in a length-prefixed string.
+func checkString(name, s string) error {
+	if len(s) > maxStringLength {
+		return fmt.Errorf("%s of %d bytes exceeds the maximum length", name, len(s))
+	}
+
+	return nil
+}
+
+// readPacket reads a control packet and returns its fixed header and remaining bytes.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to read packet: %w", err)
+	}
+
+	n := 0
+
+	for i := 0; ; i++ {
+		if i == maxRemainingBytes {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, fmt.Errorf("unable to read packet: %w", err)
+		}
+
+		n |= int(b&(remainingLenMore-1)) << (remainingLenBits * i)
+
+		if b&remainingLenMore == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, fmt.Errorf("unable to read packet: %w", err)
+	}
+
+	return header, body, nil
+}
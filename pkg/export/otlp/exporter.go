@@ -0,0 +1,291 @@
+/*
+Package otlp exports the hardware snapshot of a Raspberry Pi as OpenTelemetry metrics over OTLP/HTTP
+with binary protobuf encoding. Readings become gauges, and the board describes the resource. The
+protocol is documented here:
+
+https://opentelemetry.io/docs/specs/otlp/#otlphttp
+*/
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/internal/protowire"
+	"github.com/schubergphilis/rpi_exporter/pkg/version"
+)
+
+const (
+	contentType  = "application/x-protobuf"
+	scopeName    = "github.com/schubergphilis/rpi_exporter"
+	serviceName  = "rpi_exporter"
+	maxErrorBody = 512
+)
+
+// retryableStatus holds the response codes after which a request may be retried, per the spec.
+var retryableStatus = []int{
+	http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+}
+
+// Exporter batches snapshots and exports them to an OTLP/HTTP metrics endpoint.
+type Exporter struct {
+	// Client is the HTTP client used for requests.
+	Client *http.Client
+	// Headers are added to every request, for example for authentication.
+	Headers map[string]string
+	// Attributes are added to the resource, overriding those derived from the snapshot.
+	Attributes map[string]string
+	// Gzip compresses requests.
+	Gzip bool
+	// MaxQueued limits the snapshots kept while the endpoint is unreachable, dropping the oldest
+	// first. Zero means no limit.
+	MaxQueued int
+
+	url string
+
+	mu    sync.Mutex
+	queue []*snapshot.Snapshot
+}
+
+// New returns an exporter that sends to the metrics endpoint at endpointURL, usually ending in
+// /v1/metrics.
+func New(endpointURL string) (*Exporter, error) {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otlp url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid otlp url %q: scheme must be http or https", endpointURL)
+	}
+
+	return &Exporter{Client: http.DefaultClient, url: u.String()}, nil
+}
+
+// URL returns the URL of the endpoint.
+func (e *Exporter) URL() string {
+	return e.url
+}
+
+// Add queues a snapshot for the next Flush. It returns the number of old snapshots dropped because
+// the queue is full.
+func (e *Exporter) Add(snap *snapshot.Snapshot) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.queue = append(e.queue, snap)
+
+	return e.trim()
+}
+
+// Len returns the number of queued snapshots.
+func (e *Exporter) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return len(e.queue)
+}
+
+// Flush exports all queued snapshots in a single request. The snapshots stay queued if the request
+// can be retried, and are dropped if the endpoint rejects them permanently.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	batch := e.queue
+	e.queue = nil
+	e.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	retry, err := e.send(ctx, e.encode(batch))
+	if err != nil && retry {
+		e.mu.Lock()
+		e.queue = append(batch, e.queue...)
+		e.trim()
+		e.mu.Unlock()
+	}
+
+	return err
+}
+
+// trim drops the oldest snapshots beyond MaxQueued and returns how many were dropped.
+func (e *Exporter) trim() int {
+	if e.MaxQueued <= 0 || len(e.queue) <= e.MaxQueued {
+		return 0
+	}
+
+	dropped := len(e.queue) - e.MaxQueued
+	e.queue = slices.Delete(e.queue, 0, dropped)
+
+	return dropped
+}
+
+// resourceAttributes describes the board of the most recent snapshot with hardware information.
+func (e *Exporter) resourceAttributes(batch []*snapshot.Snapshot) map[string]string {
+	attrs := map[string]string{
+		"service.name":    serviceName,
+		"service.version": version.Get().Version,
+	}
+
+	for _, snap := range slices.Backward(batch) {
+		if !snap.Has(snapshot.SectionHardware) {
+			continue
+		}
+
+		board := snap.Board
+
+		if board.Serial.OK() {
+			attrs["host.id"] = board.Serial.Value
+		}
+
+		if board.Revision.OK() {
+			attrs["hw.model"] = fmt.Sprintf("%x", board.Revision.Value)
+		}
+
+		if board.FirmwareHash.OK() {
+			attrs["hw.firmware_version"] = board.FirmwareHash.Value
+		}
+
+		if board.FirmwareVariant.OK() {
+			attrs["rpi.firmware.variant"] = board.FirmwareVariant.Value
+		}
+
+		break
+	}
+
+	maps.Copy(attrs, e.Attributes)
+
+	return attrs
+}
+
+func (e *Exporter) encode(batch []*snapshot.Snapshot) []byte {
+	var resource protowire.Buffer
+
+	attrs := e.resourceAttributes(batch)
+	for _, key := range slices.Sorted(maps.Keys(attrs)) {
+		resource = resource.Bytes(fieldResourceAttributes, keyValue(key, attrs[key]))
+	}
+
+	var scope protowire.Buffer
+
+	scope = scope.String(fieldScopeName, scopeName).String(fieldScopeVersion, version.Get().Version)
+
+	var scopeMetrics protowire.Buffer
+
+	scopeMetrics = scopeMetrics.Bytes(fieldScopeMetricsScope, scope)
+
+	for _, m := range metrics(batch) {
+		scopeMetrics = scopeMetrics.Bytes(fieldScopeMetricsMetrics, encodeMetric(m))
+	}
+
+	var resourceMetrics protowire.Buffer
+
+	resourceMetrics = resourceMetrics.Bytes(fieldResourceMetricsResource, resource).
+		Bytes(fieldResourceMetricsScope, scopeMetrics)
+
+	var req protowire.Buffer
+
+	return req.Bytes(fieldRequestResourceMetrics, resourceMetrics)
+}
+
+func encodeMetric(m *metric) protowire.Buffer {
+	var points protowire.Buffer
+
+	field := fieldGaugeDataPoints
+	if m.sum {
+		field = fieldSumDataPoints
+	}
+
+	for _, p := range m.points {
+		var point protowire.Buffer
+
+		for _, a := range p.attributes {
+			point = point.Bytes(fieldPointAttributes, keyValue(a.key, a.value))
+		}
+
+		if m.sum {
+			point = timestamp(point, fieldPointStartTime, m.start)
+		}
+
+		point = timestamp(point, fieldPointTime, p.time).Double(fieldPointAsDouble, p.value)
+		points = points.Bytes(field, point)
+	}
+
+	var b protowire.Buffer
+
+	b = b.String(fieldMetricName, m.name).String(fieldMetricDescription, m.description).
+		String(fieldMetricUnit, m.unit)
+
+	if m.sum {
+		points = points.Varint(fieldSumTemporality, temporalityCumulative).Varint(fieldSumMonotonic, 1)
+
+		return b.Bytes(fieldMetricSum, points)
+	}
+
+	return b.Bytes(fieldMetricGauge, points)
+}
+
+// send posts an encoded request. It reports whether a failed request may be retried.
+func (e *Exporter) send(ctx context.Context, body []byte) (bool, error) {
+	encoding := ""
+
+	if e.Gzip {
+		var buf bytes.Buffer
+
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return false, fmt.Errorf("unable to compress request: %w", err)
+		}
+
+		if err := zw.Close(); err != nil {
+			return false, fmt.Errorf("unable to compress request: %w", err)
+		}
+
+		body, encoding = buf.Bytes(), "gzip"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	for key, value := range e.Headers {
+		req.Header.Set(key, value)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", serviceName+"/"+version.Get().Version)
+
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("unable to export metrics: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+
+	// The body is a protobuf Status message, whose error message is legible in the raw bytes.
+	err = fmt.Errorf("unexpected status %s from otlp endpoint: %s", resp.Status, strings.TrimSpace(string(msg)))
+
+	return slices.Contains(retryableStatus, resp.StatusCode), err
+}
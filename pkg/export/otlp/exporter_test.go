@@ -0,0 +1,269 @@
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector is an OTLP endpoint stand-in that records request bodies and answers with status.
+type collector struct {
+	mu       sync.Mutex
+	status   int
+	message  string
+	requests []*http.Request
+	bodies   [][]byte
+	// onRequest is called before answering, for example to queue snapshots during a flush.
+	onRequest func()
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := io.Reader(r.Body)
+
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		body = zr
+	}
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	c.mu.Lock()
+	c.requests = append(c.requests, r)
+	c.bodies = append(c.bodies, b)
+	status, message, onRequest := c.status, c.message, c.onRequest
+	c.mu.Unlock()
+
+	if onRequest != nil {
+		onRequest()
+	}
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, message)
+}
+
+func newTestExporter(t *testing.T, c *collector) *Exporter {
+	t.Helper()
+
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+
+	e, err := New(srv.URL + "/v1/metrics")
+	require.NoError(t, err)
+
+	return e
+}
+
+func testSnapshot(at time.Time) *snapshot.Snapshot {
+	filter, _ := snapshot.NewFilter(snapshot.SectionTemperature)
+
+	return &snapshot.Snapshot{
+		Filter:      filter,
+		Time:        at,
+		Temperature: snapshot.Temperature{Celsius: reading[float32](48, at), MaxCelsius: reading[float32](85, at)},
+	}
+}
+
+func TestNew(t *testing.T) {
+	e, err := New("https://collector:4318/v1/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, "https://collector:4318/v1/metrics", e.URL())
+
+	for _, u := range []string{"collector:4318", "grpc://collector:4317", "://"} {
+		_, err := New(u)
+		require.Error(t, err, u)
+	}
+}
+
+func TestAddTrimsQueue(t *testing.T) {
+	e, err := New("http://localhost:4318/v1/metrics")
+	require.NoError(t, err)
+
+	e.MaxQueued = 2
+
+	snaps := make([]*snapshot.Snapshot, 4)
+	for i := range snaps {
+		snaps[i] = testSnapshot(goldenTime.Add(time.Duration(i) * time.Minute))
+	}
+
+	assert.Equal(t, 0, e.Add(snaps[0]))
+	assert.Equal(t, 0, e.Add(snaps[1]))
+	assert.Equal(t, 1, e.Add(snaps[2]))
+	assert.Equal(t, 1, e.Add(snaps[3]))
+	assert.Equal(t, 2, e.Len())
+	assert.Equal(t, snaps[2:], e.queue)
+
+	e.MaxQueued = 0
+	for range 10 {
+		assert.Equal(t, 0, e.Add(snaps[0]))
+	}
+
+	assert.Equal(t, 12, e.Len())
+}
+
+func TestFlush(t *testing.T) {
+	c := &collector{}
+	e := newTestExporter(t, c)
+	e.Headers = map[string]string{"Authorization": "Bearer token"}
+
+	// Flushing an empty queue sends nothing.
+	require.NoError(t, e.Flush(context.Background()))
+	assert.Empty(t, c.requests)
+
+	batch := []*snapshot.Snapshot{testSnapshot(goldenTime), testSnapshot(goldenTime.Add(time.Minute))}
+	for _, snap := range batch {
+		e.Add(snap)
+	}
+
+	require.NoError(t, e.Flush(context.Background()))
+	assert.Equal(t, 0, e.Len())
+
+	require.Len(t, c.requests, 1)
+
+	r := c.requests[0]
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "/v1/metrics", r.URL.Path)
+	assert.Equal(t, contentType, r.Header.Get("Content-Type"))
+	assert.Equal(t, "rpi_exporter/dev", r.Header.Get("User-Agent"))
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	assert.Empty(t, r.Header.Get("Content-Encoding"))
+	assert.Equal(t, e.encode(batch), c.bodies[0])
+
+	_, _, metrics, _ := decodeRequest(t, c.bodies[0])
+	assert.Len(t, metrics["rpi.temperature"].points, 2)
+}
+
+func TestFlushGzip(t *testing.T) {
+	c := &collector{}
+	e := newTestExporter(t, c)
+	e.Gzip = true
+
+	snap := testSnapshot(goldenTime)
+	e.Add(snap)
+
+	require.NoError(t, e.Flush(context.Background()))
+	require.Len(t, c.requests, 1)
+	assert.Equal(t, "gzip", c.requests[0].Header.Get("Content-Encoding"))
+	assert.Equal(t, e.encode([]*snapshot.Snapshot{snap}), c.bodies[0])
+}
+
+func TestFlushStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		kept   bool
+	}{
+		{http.StatusAccepted, false},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusNotFound, false},
+		{http.StatusRequestEntityTooLarge, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusGatewayTimeout, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			c := &collector{status: tt.status, message: "  rejected by collector\n"}
+			e := newTestExporter(t, c)
+			e.Add(testSnapshot(goldenTime))
+
+			err := e.Flush(context.Background())
+			if tt.status/100 == 2 {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "unexpected status")
+				assert.Contains(t, err.Error(), ": rejected by collector")
+			}
+
+			if tt.kept {
+				assert.Equal(t, 1, e.Len())
+			} else {
+				assert.Equal(t, 0, e.Len())
+			}
+		})
+	}
+}
+
+func TestFlushUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	e, err := New(srv.URL)
+	require.NoError(t, err)
+
+	e.Add(testSnapshot(goldenTime))
+
+	require.Error(t, e.Flush(context.Background()))
+	assert.Equal(t, 1, e.Len())
+}
+
+func TestFlushRetryKeepsOrderAndTrims(t *testing.T) {
+	c := &collector{status: http.StatusServiceUnavailable}
+	e := newTestExporter(t, c)
+	e.MaxQueued = 3
+
+	snaps := make([]*snapshot.Snapshot, 4)
+	for i := range snaps {
+		snaps[i] = testSnapshot(goldenTime.Add(time.Duration(i) * time.Minute))
+	}
+
+	e.Add(snaps[0])
+	e.Add(snaps[1])
+
+	// Snapshots added during a failed flush are queued after the batch, and the oldest are dropped
+	// beyond MaxQueued.
+	c.onRequest = func() {
+		e.Add(snaps[2])
+		e.Add(snaps[3])
+	}
+
+	require.Error(t, e.Flush(context.Background()))
+	assert.Equal(t, snaps[1:], e.queue)
+
+	c.mu.Lock()
+	c.status, c.onRequest = http.StatusOK, nil
+	c.mu.Unlock()
+
+	require.NoError(t, e.Flush(context.Background()))
+	assert.Equal(t, 0, e.Len())
+	require.Len(t, c.bodies, 2)
+	assert.Equal(t, e.encode(snaps[1:]), c.bodies[1])
+}
+
+func TestFlushCanceled(t *testing.T) {
+	e := newTestExporter(t, &collector{})
+	e.Add(testSnapshot(goldenTime))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.ErrorIs(t, e.Flush(ctx), context.Canceled)
+	assert.Equal(t, 1, e.Len())
+}
@@ -0,0 +1,337 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+var (
+	goldenTime  = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	goldenSince = time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC)
+)
+
+func reading[T any](v T, t time.Time) snapshot.Reading[T] {
+	return snapshot.Reading[T]{Value: v, Time: t}
+}
+
+// goldenSnapshot returns a snapshot with fixed readings taken at t. The SPI power state could not be
+// read, so the golden file also covers skipped values. The sampler and exporter sections depend on
+// the running process and are left out.
+func goldenSnapshot(t *testing.T, at time.Time, celsius float32) *snapshot.Snapshot {
+	t.Helper()
+
+	filter, err := snapshot.NewFilter(
+		snapshot.SectionHardware,
+		snapshot.SectionPower,
+		snapshot.SectionClocks,
+		snapshot.SectionTemperature,
+		snapshot.SectionVoltage,
+		snapshot.SectionThrottle,
+	)
+	require.NoError(t, err)
+
+	return &snapshot.Snapshot{
+		Filter: filter,
+		Time:   at,
+		Board: snapshot.Board{
+			FirmwareRevision: reading[uint32](1679047839, at),
+			FirmwareVariant:  reading("start", at),
+			FirmwareHash:     reading("82f3750a", at),
+			Model:            reading[uint32](0, at),
+			Revision:         reading[uint32](0xa03111, at),
+			Serial:           reading("10000000deadbeef", at),
+		},
+		Clocks: map[string]snapshot.Clock{
+			"arm":  {RateHz: reading(1500000000, at), MeasuredHz: reading(1500398464, at)},
+			"core": {RateHz: reading(500000000, at), MeasuredHz: reading(500001024, at)},
+		},
+		Voltages: map[string]snapshot.Voltage{
+			"core": {
+				Volts:    reading[float32](0.86, at),
+				MinVolts: reading[float32](0.8, at),
+				MaxVolts: reading[float32](1.2, at),
+			},
+		},
+		Temperature: snapshot.Temperature{Celsius: reading(celsius, at), MaxCelsius: reading[float32](85, at)},
+		Power: map[string]snapshot.Reading[uint32]{
+			"sd_card": reading[uint32](1, at),
+			"spi":     {Time: at, Err: errors.New("mailbox read failed")},
+		},
+		Throttle: snapshot.Throttle{
+			State: reading(snapshot.NewThrottleState(0x50005), at),
+			Turbo: reading(true, at),
+		},
+		ThrottleEvents: &snapshot.ThrottleEvents{
+			Since:   goldenSince,
+			Events:  map[string]uint64{"under_voltage": 3, "throttled": 1},
+			Seconds: map[string]float64{"under_voltage": 12.5, "throttled": 2},
+			Errors:  1,
+		},
+	}
+}
+
+// goldenRequest encodes a batch of two snapshots a minute apart.
+func goldenRequest(t *testing.T) []byte {
+	t.Helper()
+
+	e, err := New("http://localhost:4318/v1/metrics")
+	require.NoError(t, err)
+
+	e.Attributes = map[string]string{"deployment.environment": "lab", "service.name": "rpi"}
+
+	return e.encode([]*snapshot.Snapshot{
+		goldenSnapshot(t, goldenTime.Add(-time.Minute), 47.5),
+		goldenSnapshot(t, goldenTime, 48.3),
+	})
+}
+
+func TestEncodeGolden(t *testing.T) {
+	got := goldenRequest(t)
+
+	path := filepath.Join("testdata", "metrics.binpb")
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o600))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+// protoField is a decoded protobuf field. Varint and fixed64 values are kept in value.
+type protoField struct {
+	number int
+	wire   int
+	value  uint64
+	bytes  []byte
+}
+
+// decodeMessage decodes the fields of a message, failing the test on malformed input.
+func decodeMessage(t *testing.T, b []byte) []protoField {
+	t.Helper()
+
+	var fields []protoField
+
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.Positive(t, n, "invalid tag")
+
+		b = b[n:]
+		f := protoField{number: int(tag >> 3), wire: int(tag & 7)}
+
+		switch f.wire {
+		case 0:
+			f.value, n = binary.Uvarint(b)
+			require.Positive(t, n, "invalid varint")
+			b = b[n:]
+		case 1:
+			require.GreaterOrEqual(t, len(b), 8, "truncated fixed64")
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			require.Positive(t, n, "invalid length")
+			require.GreaterOrEqual(t, uint64(len(b)-n), size, "truncated bytes")
+			f.bytes, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			require.Failf(t, "unexpected wire type", "field %d has wire type %d", f.number, f.wire)
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// fieldsOf returns the fields with the given number.
+func fieldsOf(fields []protoField, number int) []protoField {
+	var out []protoField
+
+	for _, f := range fields {
+		if f.number == number {
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
+
+// field returns the only field with the given number.
+func field(t *testing.T, fields []protoField, number int) protoField {
+	t.Helper()
+
+	out := fieldsOf(fields, number)
+	require.Len(t, out, 1, "field %d", number)
+
+	return out[0]
+}
+
+// decodeAttributes decodes repeated KeyValue fields with string values.
+func decodeAttributes(t *testing.T, fields []protoField, number int) map[string]string {
+	t.Helper()
+
+	attrs := map[string]string{}
+
+	for _, f := range fieldsOf(fields, number) {
+		kv := decodeMessage(t, f.bytes)
+		value := decodeMessage(t, field(t, kv, fieldKeyValueValue).bytes)
+		attrs[string(field(t, kv, fieldKeyValueKey).bytes)] = string(field(t, value, fieldAnyValueString).bytes)
+	}
+
+	return attrs
+}
+
+type decodedPoint struct {
+	attributes map[string]string
+	start      uint64
+	time       uint64
+	value      float64
+}
+
+type decodedMetric struct {
+	description string
+	unit        string
+	sum         bool
+	points      []decodedPoint
+}
+
+// decodeRequest decodes an ExportMetricsServiceRequest with a single resource and scope.
+func decodeRequest(t *testing.T, b []byte) (map[string]string, []protoField, map[string]decodedMetric, []string) {
+	t.Helper()
+
+	request := decodeMessage(t, b)
+	resourceMetrics := decodeMessage(t, field(t, request, fieldRequestResourceMetrics).bytes)
+	resource := decodeMessage(t, field(t, resourceMetrics, fieldResourceMetricsResource).bytes)
+	scopeMetrics := decodeMessage(t, field(t, resourceMetrics, fieldResourceMetricsScope).bytes)
+	scope := decodeMessage(t, field(t, scopeMetrics, fieldScopeMetricsScope).bytes)
+
+	metrics := map[string]decodedMetric{}
+
+	var names []string
+
+	for _, f := range fieldsOf(scopeMetrics, fieldScopeMetricsMetrics) {
+		m := decodeMessage(t, f.bytes)
+		name := string(field(t, m, fieldMetricName).bytes)
+		decoded := decodedMetric{
+			description: string(field(t, m, fieldMetricDescription).bytes),
+			unit:        string(field(t, m, fieldMetricUnit).bytes),
+		}
+
+		data, dataField := fieldsOf(m, fieldMetricGauge), fieldGaugeDataPoints
+		if sum := fieldsOf(m, fieldMetricSum); len(sum) > 0 {
+			data, dataField, decoded.sum = sum, fieldSumDataPoints, true
+		}
+
+		require.Len(t, data, 1, name)
+
+		points := decodeMessage(t, data[0].bytes)
+		if decoded.sum {
+			assert.Equal(t, uint64(temporalityCumulative), field(t, points, fieldSumTemporality).value, name)
+			assert.Equal(t, uint64(1), field(t, points, fieldSumMonotonic).value, name)
+		}
+
+		for _, p := range fieldsOf(points, dataField) {
+			point := decodeMessage(t, p.bytes)
+			dp := decodedPoint{
+				attributes: decodeAttributes(t, point, fieldPointAttributes),
+				time:       field(t, point, fieldPointTime).value,
+				value:      math.Float64frombits(field(t, point, fieldPointAsDouble).value),
+			}
+
+			if start := fieldsOf(point, fieldPointStartTime); len(start) > 0 {
+				dp.start = start[0].value
+			}
+
+			decoded.points = append(decoded.points, dp)
+		}
+
+		names = append(names, name)
+		metrics[name] = decoded
+	}
+
+	return decodeAttributes(t, resource, fieldResourceAttributes), scope, metrics, names
+}
+
+func TestEncodeDecodes(t *testing.T) {
+	resource, scope, metrics, names := decodeRequest(t, goldenRequest(t))
+
+	assert.Equal(t, map[string]string{
+		"service.name":           "rpi",
+		"service.version":        "dev",
+		"deployment.environment": "lab",
+		"host.id":                "10000000deadbeef",
+		"hw.model":               "a03111",
+		"hw.firmware_version":    "82f3750a",
+		"rpi.firmware.variant":   "start",
+	}, resource)
+
+	assert.Equal(t, scopeName, string(field(t, scope, fieldScopeName).bytes))
+	assert.Equal(t, "dev", string(field(t, scope, fieldScopeVersion).bytes))
+
+	assert.Equal(t, []string{
+		"rpi.power.state",
+		"rpi.clock.frequency",
+		"rpi.clock.frequency.measured",
+		"rpi.turbo",
+		"rpi.temperature",
+		"rpi.temperature.limit",
+		"rpi.voltage",
+		"rpi.voltage.min",
+		"rpi.voltage.max",
+		"rpi.throttled",
+		"rpi.throttled.occurred",
+		"rpi.throttle.events",
+		"rpi.throttle.duration",
+		"rpi.throttle.poll.errors",
+	}, names)
+
+	earlier, now := uint64(goldenTime.Add(-time.Minute).UnixNano()), uint64(goldenTime.UnixNano())
+
+	// Data points of both snapshots are merged into one metric, in order.
+	temp := metrics["rpi.temperature"]
+	assert.False(t, temp.sum)
+	assert.Equal(t, unitCelsius, temp.unit)
+	assert.Equal(t, "Temperature of the SoC.", temp.description)
+	assert.Equal(t, []decodedPoint{
+		{attributes: map[string]string{}, time: earlier, value: 47.5},
+		{attributes: map[string]string{}, time: now, value: 48.3},
+	}, temp.points)
+
+	// The failed SPI reading is skipped.
+	power := metrics["rpi.power.state"]
+	require.Len(t, power.points, 2)
+	assert.Equal(t, map[string]string{"id": "sd_card"}, power.points[0].attributes)
+
+	volts := metrics["rpi.voltage.min"]
+	require.Len(t, volts.points, 2)
+	assert.Equal(t, decodedPoint{attributes: map[string]string{"id": "core"}, time: now, value: 0.8}, volts.points[1])
+
+	events := metrics["rpi.throttle.events"]
+	assert.True(t, events.sum)
+	require.Len(t, events.points, 2*len(snapshot.ThrottleConditions))
+	assert.Equal(t, decodedPoint{
+		attributes: map[string]string{"condition": "under_voltage"},
+		start:      uint64(goldenSince.UnixNano()),
+		time:       earlier,
+		value:      3,
+	}, events.points[0])
+
+	active := metrics["rpi.throttled"]
+	require.Len(t, active.points, 2*len(snapshot.ThrottleConditions))
+
+	for _, p := range active.points {
+		want := map[string]float64{"under_voltage": 1, "freq_capped": 0, "throttled": 1, "soft_temp_limit": 0}
+		assert.InDelta(t, want[p.attributes["condition"]], p.value, 0, p.attributes["condition"])
+	}
+}
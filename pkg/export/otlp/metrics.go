@@ -0,0 +1,299 @@
+package otlp
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+// Units in UCUM notation, as recommended by the OpenTelemetry semantic conventions.
+const (
+	unitCelsius = "Cel"
+	unitHertz   = "Hz"
+	unitVolts   = "V"
+	unitSeconds = "s"
+	unitBytes   = "By"
+	unitNone    = "1"
+)
+
+type attribute struct {
+	key, value string
+}
+
+type dataPoint struct {
+	attributes []attribute
+	time       time.Time
+	value      float64
+}
+
+// metric is a gauge, or a cumulative monotonic sum if sum is set.
+type metric struct {
+	name        string
+	description string
+	unit        string
+	sum         bool
+	start       time.Time
+	points      []dataPoint
+}
+
+func (m *metric) add(t time.Time, v float64, attrs ...attribute) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	m.points = append(m.points, dataPoint{attributes: attrs, time: t, value: v})
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// sectionMetrics maps the sections of a snapshot to the function converting them into metrics. The
+// hardware section is not included; it describes the resource instead.
+var sectionMetrics = map[string]func(*snapshot.Snapshot) []*metric{
+	snapshot.SectionPower:       powerMetrics,
+	snapshot.SectionClocks:      clockMetrics,
+	snapshot.SectionTemperature: temperatureMetrics,
+	snapshot.SectionVoltage:     voltageMetrics,
+	snapshot.SectionThrottle:    throttleMetrics,
+	snapshot.SectionSampler:     samplerMetrics,
+	snapshot.SectionExporter:    exporterMetrics,
+}
+
+// metrics converts snapshots into metrics, merging the data points of metrics with the same name in
+// the order the snapshots are given.
+func metrics(snaps []*snapshot.Snapshot) []*metric {
+	var out []*metric
+
+	byName := map[string]*metric{}
+
+	for _, snap := range snaps {
+		for _, section := range snapshot.Sections {
+			convert, ok := sectionMetrics[section]
+			if !ok || !snap.Has(section) {
+				continue
+			}
+
+			for _, m := range convert(snap) {
+				if len(m.points) == 0 {
+					continue
+				}
+
+				if prev, ok := byName[m.name]; ok {
+					prev.points = append(prev.points, m.points...)
+
+					continue
+				}
+
+				byName[m.name] = m
+				out = append(out, m)
+			}
+		}
+	}
+
+	return out
+}
+
+func powerMetrics(snap *snapshot.Snapshot) []*metric {
+	power := &metric{
+		name: "rpi.power.state", description: "Component power state (0: off, 1: on, 2: missing).", unit: unitNone,
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(snap.Power)) {
+		if state := snap.Power[id]; state.OK() {
+			power.add(state.Time, float64(state.Value), attribute{"id", id})
+		}
+	}
+
+	return []*metric{power}
+}
+
+func clockMetrics(snap *snapshot.Snapshot) []*metric {
+	rate := &metric{name: "rpi.clock.frequency", description: "Configured clock frequency.", unit: unitHertz}
+	measured := &metric{
+		name: "rpi.clock.frequency.measured", description: "Measured clock frequency.", unit: unitHertz,
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(snap.Clocks)) {
+		clock := snap.Clocks[id]
+
+		if clock.RateHz.OK() {
+			rate.add(clock.RateHz.Time, float64(clock.RateHz.Value), attribute{"id", id})
+		}
+
+		if clock.MeasuredHz.OK() {
+			measured.add(clock.MeasuredHz.Time, float64(clock.MeasuredHz.Value), attribute{"id", id})
+		}
+	}
+
+	turbo := &metric{name: "rpi.turbo", description: "Turbo state.", unit: unitNone}
+	if r := snap.Throttle.Turbo; r.OK() {
+		turbo.add(r.Time, boolValue(r.Value))
+	}
+
+	return []*metric{rate, measured, turbo}
+}
+
+func temperatureMetrics(snap *snapshot.Snapshot) []*metric {
+	temp := &metric{name: "rpi.temperature", description: "Temperature of the SoC.", unit: unitCelsius}
+	if r := snap.Temperature.Celsius; r.OK() {
+		temp.add(r.Time, snapshot.Float64(r.Value))
+	}
+
+	limit := &metric{
+		name: "rpi.temperature.limit", description: "Maximum safe temperature of the SoC.", unit: unitCelsius,
+	}
+	if r := snap.Temperature.MaxCelsius; r.OK() {
+		limit.add(r.Time, snapshot.Float64(r.Value))
+	}
+
+	return []*metric{temp, limit}
+}
+
+func voltageMetrics(snap *snapshot.Snapshot) []*metric {
+	volts := &metric{name: "rpi.voltage", description: "Current component voltage.", unit: unitVolts}
+	minVolts := &metric{name: "rpi.voltage.min", description: "Minimum supported voltage.", unit: unitVolts}
+	maxVolts := &metric{name: "rpi.voltage.max", description: "Maximum supported voltage.", unit: unitVolts}
+
+	for _, id := range slices.Sorted(maps.Keys(snap.Voltages)) {
+		voltage := snap.Voltages[id]
+
+		for _, v := range []struct {
+			m *metric
+			r snapshot.Reading[float32]
+		}{{volts, voltage.Volts}, {minVolts, voltage.MinVolts}, {maxVolts, voltage.MaxVolts}} {
+			if v.r.OK() {
+				v.m.add(v.r.Time, snapshot.Float64(v.r.Value), attribute{"id", id})
+			}
+		}
+	}
+
+	return []*metric{volts, minVolts, maxVolts}
+}
+
+func throttleMetrics(snap *snapshot.Snapshot) []*metric {
+	active := &metric{
+		name: "rpi.throttled", description: "Throttle condition currently active.", unit: unitNone,
+	}
+	occurred := &metric{
+		name:        "rpi.throttled.occurred",
+		description: "Throttle condition occurred since boot, or since the last poll of the throttle monitor.",
+		unit:        unitNone,
+	}
+
+	if r := snap.Throttle.State; r.OK() {
+		for _, cond := range snapshot.ThrottleConditions {
+			active.add(r.Time, boolValue(r.Value.Flags&cond.Active != 0), attribute{"condition", cond.Name})
+			occurred.add(r.Time, boolValue(r.Value.Flags&cond.Occurred != 0), attribute{"condition", cond.Name})
+		}
+	}
+
+	metrics := []*metric{active, occurred}
+
+	if events := snap.ThrottleEvents; events != nil {
+		count := &metric{
+			name: "rpi.throttle.events", description: "Number of times a throttle condition occurred.",
+			unit: unitNone, sum: true, start: events.Since,
+		}
+		duration := &metric{
+			name: "rpi.throttle.duration", description: "Time a throttle condition was active.",
+			unit: unitSeconds, sum: true, start: events.Since,
+		}
+
+		for _, cond := range snapshot.ThrottleConditions {
+			count.add(snap.Time, float64(events.Events[cond.Name]), attribute{"condition", cond.Name})
+			duration.add(snap.Time, events.Seconds[cond.Name], attribute{"condition", cond.Name})
+		}
+
+		pollErrors := &metric{
+			name: "rpi.throttle.poll.errors", description: "Number of failed polls of the throttled state.",
+			unit: unitNone, sum: true, start: events.Since,
+		}
+		pollErrors.add(snap.Time, float64(events.Errors))
+
+		metrics = append(metrics, count, duration, pollErrors)
+	}
+
+	return metrics
+}
+
+func samplerMetrics(snap *snapshot.Snapshot) []*metric {
+	samples := snap.Samples
+	if samples == nil {
+		return nil
+	}
+
+	tempMin := &metric{
+		name: "rpi.sampled.temperature.min", description: "Minimum sampled temperature since the last export.",
+		unit: unitCelsius,
+	}
+	tempMin.add(snap.Time, samples.Temperature.Min)
+
+	tempMax := &metric{
+		name: "rpi.sampled.temperature.max", description: "Maximum sampled temperature since the last export.",
+		unit: unitCelsius,
+	}
+	tempMax.add(snap.Time, samples.Temperature.Max)
+
+	clockMin := &metric{
+		name: "rpi.sampled.clock.frequency.min", description: "Minimum sampled clock frequency since the last export.",
+		unit: unitHertz,
+	}
+	clockMax := &metric{
+		name: "rpi.sampled.clock.frequency.max", description: "Maximum sampled clock frequency since the last export.",
+		unit: unitHertz,
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(samples.Clocks)) {
+		clockMin.add(snap.Time, samples.Clocks[id].Min, attribute{"id", id})
+		clockMax.add(snap.Time, samples.Clocks[id].Max, attribute{"id", id})
+	}
+
+	return []*metric{tempMin, tempMax, clockMin, clockMax}
+}
+
+func exporterMetrics(snap *snapshot.Snapshot) []*metric {
+	exp := snap.Exporter
+
+	duration := &metric{
+		name: "rpi_exporter.collection.duration", description: "Time taken to collect all hardware readings.",
+		unit: unitSeconds,
+	}
+	duration.add(snap.Time, snap.Duration.Seconds())
+
+	requests := &metric{
+		name: "rpi_exporter.mailbox.requests", description: "Number of mailbox ioctl calls.",
+		unit: unitNone, sum: true, start: exp.Mailbox.Since,
+	}
+	requests.add(snap.Time, float64(exp.Mailbox.Count))
+
+	requestErrors := &metric{
+		name: "rpi_exporter.mailbox.errors", description: "Number of failed mailbox ioctl calls.",
+		unit: unitNone, sum: true, start: exp.Mailbox.Since,
+	}
+	requestErrors.add(snap.Time, float64(exp.Mailbox.Errors))
+
+	metrics := []*metric{duration, requests, requestErrors}
+
+	if proc := exp.Process; proc.OK() {
+		cpu := &metric{
+			name: "process.cpu.time", description: "Total user and system CPU time spent.",
+			unit: unitSeconds, sum: true, start: proc.Value.StartTime,
+		}
+		cpu.add(proc.Time, proc.Value.CPUSeconds)
+
+		memory := &metric{name: "process.memory.usage", description: "Resident memory size.", unit: unitBytes}
+		memory.add(proc.Time, float64(proc.Value.ResidentBytes))
+
+		metrics = append(metrics, cpu, memory)
+	}
+
+	return metrics
+}
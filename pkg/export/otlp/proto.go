@@ -0,0 +1,73 @@
+package otlp
+
+// OTLP metrics are opentelemetry.proto.collector.metrics.v1.ExportMetricsServiceRequest messages:
+//
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/metrics/v1/metrics.proto
+
+import (
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/internal/protowire"
+)
+
+// opentelemetry.proto.metrics.v1.AggregationTemporality values.
+const temporalityCumulative = 2
+
+// Field numbers of opentelemetry.proto messages.
+const (
+	fieldRequestResourceMetrics = 1
+
+	fieldResourceMetricsResource = 1
+	fieldResourceMetricsScope    = 2
+
+	fieldResourceAttributes = 1
+
+	fieldScopeMetricsScope   = 1
+	fieldScopeMetricsMetrics = 2
+
+	fieldScopeName    = 1
+	fieldScopeVersion = 2
+
+	fieldKeyValueKey   = 1
+	fieldKeyValueValue = 2
+
+	fieldAnyValueString = 1
+
+	fieldMetricName        = 1
+	fieldMetricDescription = 2
+	fieldMetricUnit        = 3
+	fieldMetricGauge       = 5
+	fieldMetricSum         = 7
+
+	fieldGaugeDataPoints = 1
+
+	fieldSumDataPoints  = 1
+	fieldSumTemporality = 2
+	fieldSumMonotonic   = 3
+
+	fieldPointStartTime  = 2
+	fieldPointTime       = 3
+	fieldPointAsDouble   = 4
+	fieldPointAttributes = 7
+)
+
+// timestamp appends a time as fixed64 nanoseconds since the epoch, leaving out the zero time.
+func timestamp(b protowire.Buffer, field int, t time.Time) protowire.Buffer {
+	if t.IsZero() {
+		return b
+	}
+
+	return b.Fixed64(field, uint64(t.UnixNano()))
+}
+
+// keyValue encodes a string attribute.
+func keyValue(key, value string) protowire.Buffer {
+	var v protowire.Buffer
+
+	// Values are part of a oneof, so an empty string is encoded to mark it as set.
+	v = v.Bytes(fieldAnyValueString, []byte(value))
+
+	var kv protowire.Buffer
+
+	return kv.String(fieldKeyValueKey, key).Bytes(fieldKeyValueValue, v)
+}
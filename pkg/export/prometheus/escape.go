@@ -0,0 +1,92 @@
+package prometheus
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	labelValueEscaper      = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper            = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	openMetricsHelpEscaper = labelValueEscaper
+)
+
+// isValidMetricName reports whether name matches [a-zA-Z_:][a-zA-Z0-9_:]*.
+func isValidMetricName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		if !isNameChar(c, i == 0) && c != ':' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isValidLabelName reports whether name matches [a-zA-Z_][a-zA-Z0-9_]* and is not reserved.
+func isValidLabelName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return false
+	}
+
+	for i, c := range name {
+		if !isNameChar(c, i == 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNameChar(c rune, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// validateFamily checks the metric and label names of a family before it is written.
+func validateFamily(fam *metricFamily) error {
+	if !isValidMetricName(fam.name) {
+		return fmt.Errorf("invalid metric name %q", fam.name)
+	}
+
+	if fam.unit != "" && !strings.HasSuffix(fam.name, "_"+fam.unit) {
+		return fmt.Errorf("metric name %q must have unit %q as suffix", fam.name, fam.unit)
+	}
+
+	for _, m := range fam.metrics {
+		seen := make(map[string]bool, len(m.labels))
+
+		for _, l := range m.labels {
+			if !isValidLabelName(l.name) {
+				return fmt.Errorf("invalid label name %q for metric %q", l.name, fam.name)
+			}
+
+			if seen[l.name] {
+				return fmt.Errorf("duplicate label name %q for metric %q", l.name, fam.name)
+			}
+
+			seen[l.name] = true
+		}
+	}
+
+	return nil
+}
+
+// formatFloat formats a sample value, spelling out the special values as the exposition formats
+// require.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,144 @@
+package prometheus
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	errDanglingEscape = errors.New("dangling escape")
+	errUnknownEscape  = errors.New("unknown escape")
+)
+
+// unescape reverses the escaping done by the exposition formats, rejecting escapes outside of allowed.
+func unescape(s, allowed string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			return "", errDanglingEscape
+		}
+
+		switch c := s[i]; {
+		case !strings.ContainsRune(allowed, rune(c)):
+			return "", errUnknownEscape
+		case c == 'n':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// stripEscapes drops all escape sequences from s, leaving the characters that are written raw.
+func stripEscapes(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+
+			continue
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+func FuzzEscapeLabelValue(f *testing.F) {
+	for _, s := range []string{"", "plain", `back\slash`, "new\nline", `"quoted"`, `\n`, `\"`, "\\\n\"", "ünï©ode"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		escaped := labelValueEscaper.Replace(s)
+
+		// Every quote must be escaped, so the value cannot terminate the quoted string early.
+		if strings.ContainsAny(stripEscapes(escaped), "\n\"") {
+			t.Fatalf("escaped label value %q contains a newline or unescaped quote", escaped)
+		}
+
+		got, err := unescape(escaped, `\n"`)
+		if err != nil {
+			t.Fatalf("unable to unescape %q: %v", escaped, err)
+		}
+
+		if got != s {
+			t.Fatalf("round trip of %q gave %q", s, got)
+		}
+	})
+}
+
+func FuzzEscapeHelp(f *testing.F) {
+	for _, s := range []string{"", "Help text.", `back\slash`, "new\nline", `"quoted"`, `\n`, "\\\n\""} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		escaped := helpEscaper.Replace(s)
+
+		if strings.Contains(escaped, "\n") {
+			t.Fatalf("escaped help %q contains a newline", escaped)
+		}
+
+		// The text format only escapes backslashes and newlines in HELP.
+		got, err := unescape(escaped, `\n`)
+		if err != nil {
+			t.Fatalf("unable to unescape %q: %v", escaped, err)
+		}
+
+		if got != s {
+			t.Fatalf("round trip of %q gave %q", s, got)
+		}
+
+		escaped = openMetricsHelpEscaper.Replace(s)
+
+		if strings.ContainsAny(stripEscapes(escaped), "\n\"") {
+			t.Fatalf("escaped OpenMetrics help %q contains a newline or unescaped quote", escaped)
+		}
+
+		got, err = unescape(escaped, `\n"`)
+		if err != nil {
+			t.Fatalf("unable to unescape %q: %v", escaped, err)
+		}
+
+		if got != s {
+			t.Fatalf("OpenMetrics round trip of %q gave %q", s, got)
+		}
+	})
+}
+
+func FuzzValidNames(f *testing.F) {
+	for _, s := range []string{
+		"", "rpi_temperature", ":colon", "__reserved", "_under", "0digit", "a0", "ünï", "a-b", "a:b",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if got, want := isValidMetricName(s), metricNameRE.MatchString(s); got != want {
+			t.Fatalf("isValidMetricName(%q) = %v, want %v", s, got, want)
+		}
+
+		want := labelNameRE.MatchString(s) && !strings.HasPrefix(s, "__")
+		if got := isValidLabelName(s); got != want {
+			t.Fatalf("isValidLabelName(%q) = %v, want %v", s, got, want)
+		}
+	})
+}
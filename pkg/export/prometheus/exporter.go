@@ -0,0 +1,122 @@
+package prometheus
+
+import (
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+)
+
+// exporterFamilies converts the self-instrumentation of the exporter into metric families.
+func exporterFamilies(snap *snapshot.Snapshot) []*metricFamily {
+	exp := snap.Exporter
+
+	build := &metricFamily{
+		name: "rpi_exporter_build", help: "Build information of rpi_exporter.", typ: metricTypeInfo,
+	}
+	build.add(1,
+		label("commit", exp.Build.Commit),
+		label("goversion", exp.Build.GoVersion),
+		label("version", exp.Build.Version),
+	)
+
+	scrapeDuration := &metricFamily{
+		name: "rpi_exporter_scrape_duration_seconds", help: "Time taken to collect all hardware readings.",
+		unit: "seconds", typ: metricTypeGauge,
+	}
+	scrapeDuration.add(snap.Duration.Seconds())
+
+	cacheHits := &metricFamily{
+		name: "rpi_exporter_cache_hits", help: "Number of scrapes served from a cached snapshot.",
+		typ: metricTypeCounter,
+	}
+	cacheHits.add(float64(exp.Cache.Hits))
+
+	sampleAge := &metricFamily{
+		name: "rpi_exporter_sample_age_seconds", help: "Age of the served snapshot in seconds.",
+		unit: "seconds", typ: metricTypeGauge,
+	}
+	sampleAge.add(exp.Cache.Age.Seconds())
+
+	fams := []*metricFamily{build, scrapeDuration, cacheHits, sampleAge}
+	fams = append(fams, mailboxFamilies(exp.Mailbox)...)
+	fams = append(fams, processFamilies(exp)...)
+
+	return fams
+}
+
+func mailboxFamilies(stats mbox.IoctlStats) []*metricFamily {
+	requests := &metricFamily{
+		name: "rpi_exporter_mailbox_requests", help: "Number of mailbox ioctl calls.", typ: metricTypeCounter,
+	}
+	requests.addCounter(float64(stats.Count), stats.Since)
+
+	requestErrors := &metricFamily{
+		name: "rpi_exporter_mailbox_request_errors", help: "Number of failed mailbox ioctl calls.",
+		typ: metricTypeCounter,
+	}
+	requestErrors.addCounter(float64(stats.Errors), stats.Since)
+
+	h := &histogram{count: stats.Count, sum: stats.Sum}
+	for i, upper := range mbox.IoctlLatencyBuckets {
+		h.buckets = append(h.buckets, bucket{upperBound: upper, count: stats.Buckets[i]})
+	}
+
+	latency := &metricFamily{
+		name: "rpi_exporter_mailbox_request_duration_seconds", help: "Latency of mailbox ioctl calls.",
+		unit: "seconds", typ: metricTypeHistogram,
+	}
+	latency.addHistogram(h, stats.Since)
+
+	return []*metricFamily{requests, requestErrors, latency}
+}
+
+func processFamilies(exp snapshot.Exporter) []*metricFamily {
+	cpu := &metricFamily{
+		name: "process_cpu_seconds", help: "Total user and system CPU time spent in seconds.", unit: "seconds",
+		typ: metricTypeCounter,
+	}
+	rss := &metricFamily{
+		name: "process_resident_memory_bytes", help: "Resident memory size in bytes.", unit: "bytes",
+		typ: metricTypeGauge,
+	}
+	vsize := &metricFamily{
+		name: "process_virtual_memory_bytes", help: "Virtual memory size in bytes.", unit: "bytes",
+		typ: metricTypeGauge,
+	}
+	openFDs := &metricFamily{name: "process_open_fds", help: "Number of open file descriptors.", typ: metricTypeGauge}
+	maxFDs := &metricFamily{
+		name: "process_max_fds", help: "Maximum number of open file descriptors.", typ: metricTypeGauge,
+	}
+	startTime := &metricFamily{
+		name: "process_start_time_seconds", help: "Start time of the process since unix epoch in seconds.",
+		unit: "seconds", typ: metricTypeGauge,
+	}
+
+	if r := exp.Process; r.OK() {
+		p := r.Value
+		cpu.addCounter(p.CPUSeconds, p.StartTime)
+		rss.add(float64(p.ResidentBytes))
+		vsize.add(float64(p.VirtualBytes))
+		openFDs.add(float64(p.OpenFDs))
+		maxFDs.add(float64(p.MaxFDs))
+		startTime.add(unixSeconds(p.StartTime))
+	}
+
+	goroutines := &metricFamily{
+		name: "go_goroutines", help: "Number of goroutines that currently exist.", typ: metricTypeGauge,
+	}
+	goroutines.add(float64(exp.Runtime.Goroutines))
+
+	heapAlloc := &metricFamily{
+		name: "go_memstats_heap_alloc_bytes", help: "Number of heap bytes allocated and still in use.",
+		unit: "bytes", typ: metricTypeGauge,
+	}
+	heapAlloc.add(float64(exp.Runtime.HeapAllocBytes))
+
+	sys := &metricFamily{
+		name: "go_memstats_sys_bytes", help: "Number of bytes obtained from system.", unit: "bytes",
+		typ: metricTypeGauge,
+	}
+	sys.add(float64(exp.Runtime.SysBytes))
+
+	return []*metricFamily{cpu, rss, vsize, openFDs, maxFDs, startTime, goroutines, heapAlloc, sys}
+}
@@ -0,0 +1,270 @@
+package prometheus
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+type metricType string
+
+const (
+	metricTypeGauge     metricType = "gauge"
+	metricTypeCounter   metricType = "counter"
+	metricTypeInfo      metricType = "info"
+	metricTypeHistogram metricType = "histogram"
+)
+
+const (
+	fahrenheitFactorNumerator   = 9
+	fahrenheitFactorDenominator = 5
+	fahrenheitOffset            = 32
+)
+
+type labelPair struct {
+	name  string
+	value string
+}
+
+// metric is a single sample of a metric family. The created timestamp is only used by counters
+// and histograms, the histogram only by histograms.
+type metric struct {
+	labels    []labelPair
+	value     float64
+	created   time.Time
+	histogram *histogram
+}
+
+// bucket is a histogram bucket with a cumulative count.
+type bucket struct {
+	upperBound float64
+	count      uint64
+}
+
+// histogram holds the buckets of a histogram, excluding the implicit +Inf bucket.
+type histogram struct {
+	count   uint64
+	sum     float64
+	buckets []bucket
+}
+
+// metricFamily is a format-independent metric family. The name excludes the type suffixes added by
+// the exposition formats, such as _total for counters and _info for info metrics.
+type metricFamily struct {
+	name    string
+	help    string
+	unit    string
+	typ     metricType
+	metrics []metric
+}
+
+func (f *metricFamily) add(value float64, labels ...labelPair) {
+	f.metrics = append(f.metrics, metric{labels: labels, value: value})
+}
+
+func (f *metricFamily) addCounter(value float64, created time.Time, labels ...labelPair) {
+	f.metrics = append(f.metrics, metric{labels: labels, value: value, created: created})
+}
+
+func (f *metricFamily) addHistogram(h *histogram, created time.Time, labels ...labelPair) {
+	f.metrics = append(f.metrics, metric{labels: labels, histogram: h, created: created})
+}
+
+func label(name, value string) labelPair {
+	return labelPair{name: name, value: value}
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func fahrenheit(c float32) float32 {
+	return c*fahrenheitFactorNumerator/fahrenheitFactorDenominator + fahrenheitOffset
+}
+
+// sectionFamilies maps the sections of a snapshot to the functions converting them.
+var sectionFamilies = map[string]func(*snapshot.Snapshot) []*metricFamily{
+	snapshot.SectionHardware:    hardwareFamilies,
+	snapshot.SectionPower:       powerFamilies,
+	snapshot.SectionClocks:      clockFamilies,
+	snapshot.SectionTemperature: temperatureFamilies,
+	snapshot.SectionVoltage:     voltageFamilies,
+	snapshot.SectionThrottle:    throttleFamilies,
+	snapshot.SectionSampler:     samplerFamilies,
+	snapshot.SectionExporter:    exporterFamilies,
+}
+
+// families converts the sections included in a snapshot into metric families. Readings that
+// failed are omitted. Families are sorted by name and series by label values, so that output is
+// stable between scrapes.
+func families(snap *snapshot.Snapshot) []*metricFamily {
+	var fams []*metricFamily
+
+	for _, section := range snapshot.Sections {
+		if snap.Has(section) {
+			fams = append(fams, sectionFamilies[section](snap)...)
+		}
+	}
+
+	sortFamilies(fams)
+
+	return fams
+}
+
+func sortFamilies(fams []*metricFamily) {
+	slices.SortStableFunc(fams, func(a, b *metricFamily) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	for _, fam := range fams {
+		slices.SortStableFunc(fam.metrics, func(a, b metric) int {
+			return slices.CompareFunc(a.labels, b.labels, func(x, y labelPair) int {
+				if c := strings.Compare(x.name, y.name); c != 0 {
+					return c
+				}
+
+				return strings.Compare(x.value, y.value)
+			})
+		})
+	}
+}
+
+func hardwareFamilies(snap *snapshot.Snapshot) []*metricFamily {
+	vcRevision := &metricFamily{
+		name: "rpi_vc_revision", help: "Firmware revision of the VideoCore device.", typ: metricTypeGauge,
+	}
+	if r := snap.Board.FirmwareRevision; r.OK() {
+		vcRevision.add(float64(r.Value))
+	}
+
+	boardModel := &metricFamily{name: "rpi_board_model", help: "Board model.", typ: metricTypeGauge}
+	if r := snap.Board.Model; r.OK() {
+		boardModel.add(float64(r.Value))
+	}
+
+	boardRevision := &metricFamily{name: "rpi_board_revision", help: "Board revision.", typ: metricTypeGauge}
+	if r := snap.Board.Revision; r.OK() {
+		boardRevision.add(float64(r.Value))
+	}
+
+	board := &metricFamily{name: "rpi_board", help: "Board information.", typ: metricTypeInfo}
+	if snap.Board.Model.OK() && snap.Board.Revision.OK() {
+		board.add(1,
+			label("model", strconv.FormatUint(uint64(snap.Board.Model.Value), 10)),
+			label("revision", fmt.Sprintf("%x", snap.Board.Revision.Value)),
+		)
+	}
+
+	firmware := &metricFamily{name: "rpi_firmware", help: "VideoCore firmware information.", typ: metricTypeInfo}
+	if b := snap.Board; b.FirmwareRevision.OK() && b.FirmwareHash.OK() && b.FirmwareVariant.OK() {
+		firmware.add(1,
+			label("hash", b.FirmwareHash.Value),
+			label("revision", strconv.FormatUint(uint64(b.FirmwareRevision.Value), 10)),
+			label("variant", b.FirmwareVariant.Value),
+		)
+	}
+
+	return []*metricFamily{vcRevision, boardModel, boardRevision, board, firmware}
+}
+
+func powerFamilies(snap *snapshot.Snapshot) []*metricFamily {
+	power := &metricFamily{
+		name: "rpi_power_state", help: "Component power state (0: off, 1: on, 2: missing).", typ: metricTypeGauge,
+	}
+
+	for id, state := range snap.Power {
+		if state.OK() {
+			power.add(float64(state.Value), label("id", id))
+		}
+	}
+
+	return []*metricFamily{power}
+}
+
+func clockFamilies(snap *snapshot.Snapshot) []*metricFamily {
+	rate := &metricFamily{name: "rpi_clock_rate_hz", help: "Clock rate in Hertz.", unit: "hz", typ: metricTypeGauge}
+	measured := &metricFamily{
+		name: "rpi_clock_rate_measured_hz", help: "Measured clock rate in Hertz.", unit: "hz", typ: metricTypeGauge,
+	}
+
+	for id, clock := range snap.Clocks {
+		if clock.RateHz.OK() {
+			rate.add(float64(clock.RateHz.Value), label("id", id))
+		}
+
+		if clock.MeasuredHz.OK() {
+			measured.add(float64(clock.MeasuredHz.Value), label("id", id))
+		}
+	}
+
+	turbo := &metricFamily{name: "rpi_turbo", help: "Turbo state.", typ: metricTypeGauge}
+	if r := snap.Throttle.Turbo; r.OK() {
+		turbo.add(boolValue(r.Value))
+	}
+
+	return []*metricFamily{rate, measured, turbo}
+}
+
+func temperatureFamilies(snap *snapshot.Snapshot) []*metricFamily {
+	tempC := &metricFamily{
+		name: "rpi_temperature_c", help: "Temperature of the SoC in degrees celsius.", unit: "c", typ: metricTypeGauge,
+	}
+	tempF := &metricFamily{
+		name: "rpi_temperature_f", help: "Temperature of the SoC in degrees fahrenheit.", unit: "f", typ: metricTypeGauge,
+	}
+
+	if r := snap.Temperature.Celsius; r.OK() {
+		tempC.add(snapshot.Float64(r.Value), label("id", "soc"))
+		tempF.add(snapshot.Float64(fahrenheit(r.Value)), label("id", "soc"))
+	}
+
+	maxC := &metricFamily{
+		name: "rpi_max_temperature_c", help: "Maximum temperature of the SoC in degrees celsius.", unit: "c",
+		typ: metricTypeGauge,
+	}
+	maxF := &metricFamily{
+		name: "rpi_max_temperature_f", help: "Maximum temperature of the SoC in degrees fahrenheit.", unit: "f",
+		typ: metricTypeGauge,
+	}
+
+	if r := snap.Temperature.MaxCelsius; r.OK() {
+		maxC.add(snapshot.Float64(r.Value), label("id", "soc"))
+		maxF.add(snapshot.Float64(fahrenheit(r.Value)), label("id", "soc"))
+	}
+
+	return []*metricFamily{tempC, tempF, maxC, maxF}
+}
+
+func voltageFamilies(snap *snapshot.Snapshot) []*metricFamily {
+	volts := &metricFamily{name: "rpi_voltage", help: "Current component voltage.", typ: metricTypeGauge}
+	minVolts := &metricFamily{name: "rpi_voltage_min", help: "Minimum supported component voltage.", typ: metricTypeGauge}
+	maxVolts := &metricFamily{name: "rpi_voltage_max", help: "Maximum supported component voltage.", typ: metricTypeGauge}
+
+	for id, v := range snap.Voltages {
+		if v.Volts.OK() {
+			volts.add(snapshot.Float64(v.Volts.Value), label("id", id))
+		}
+
+		if v.MinVolts.OK() {
+			minVolts.add(snapshot.Float64(v.MinVolts.Value), label("id", id))
+		}
+
+		if v.MaxVolts.OK() {
+			maxVolts.add(snapshot.Float64(v.MaxVolts.Value), label("id", id))
+		}
+	}
+
+	return []*metricFamily{volts, minVolts, maxVolts}
+}
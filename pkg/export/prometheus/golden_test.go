@@ -0,0 +1,87 @@
+package prometheus
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot/mocks"
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+var errTestRead = errors.New("mailbox read failed")
+
+// goldenSource returns a source with fixed readings. The SPI power state cannot be read, so the
+// golden files also cover skipped values.
+func goldenSource(t *testing.T) *mocks.Source {
+	t.Helper()
+
+	src := mocks.NewSource(t)
+	src.EXPECT().GetFirmwareRevision().Return(1679047839, nil)
+	src.EXPECT().GetFirmwareVariant().Return(mbox.FirmwareVariant(1), nil)
+	src.EXPECT().GetFirmwareHash().Return("82f3750a\"quoted\\path\nline", nil)
+	src.EXPECT().GetBoardModel().Return(0, nil)
+	src.EXPECT().GetBoardRevision().Return(0xa03111, nil)
+	src.EXPECT().GetBoardSerial().Return(0x10000000deadbeef, nil)
+	src.EXPECT().GetPowerState(mbox.PowerDeviceIDSPI).Return(0, errTestRead)
+	src.EXPECT().GetPowerState(mock.Anything).Return(1, nil)
+	src.EXPECT().GetClockRate(mock.Anything).Return(1500000000, nil)
+	src.EXPECT().GetClockRateMeasured(mock.Anything).Return(1500398464, nil)
+	src.EXPECT().GetTurbo().Return(true, nil)
+	src.EXPECT().GetTemperature().Return(48.312, nil)
+	src.EXPECT().GetMaxTemperature().Return(85, nil)
+	src.EXPECT().GetVoltage(mock.Anything).Return(0.86, nil)
+	src.EXPECT().GetMinVoltage(mock.Anything).Return(0.8, nil)
+	src.EXPECT().GetMaxVoltage(mock.Anything).Return(1.2, nil)
+	src.EXPECT().GetThrottled().Return(0x50005, nil)
+
+	return src
+}
+
+func TestRenderGolden(t *testing.T) {
+	// The sampler and exporter sections depend on the running process and are left out.
+	filter, err := snapshot.NewFilter(
+		snapshot.SectionHardware,
+		snapshot.SectionPower,
+		snapshot.SectionClocks,
+		snapshot.SectionTemperature,
+		snapshot.SectionVoltage,
+		snapshot.SectionThrottle,
+	)
+	require.NoError(t, err)
+
+	snap := snapshot.CollectFrom(goldenSource(t), filter)
+
+	for _, tt := range []struct {
+		format Format
+		file   string
+	}{
+		{FormatText, "text.prom"},
+		{FormatOpenMetrics, "openmetrics.prom"},
+		{FormatProtobuf, "protobuf.prom"},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			require.NoError(t, RenderFormat(&buf, snap, tt.format))
+
+			path := filepath.Join("testdata", tt.file)
+			if *update {
+				require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+			}
+
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), buf.String())
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package prometheus
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Format is a Prometheus exposition format.
+type Format int
+
+const (
+	// FormatText is the classic text-based exposition format, version 0.0.4.
+	FormatText Format = iota
+	// FormatOpenMetrics is the OpenMetrics 1.0 text format.
+	FormatOpenMetrics
+	// FormatProtobuf is the delimited protobuf format of io.prometheus.client.MetricFamily messages.
+	FormatProtobuf
+)
+
+const (
+	mediaTypeText        = "text/plain"
+	mediaTypeOpenMetrics = "application/openmetrics-text"
+	openMetricsVersion   = "1.0.0"
+	mediaTypeProtobuf    = "application/vnd.google.protobuf"
+	protobufProto        = "io.prometheus.client.MetricFamily"
+	protobufEncoding     = "delimited"
+)
+
+// ContentType returns the HTTP Content-Type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatOpenMetrics:
+		return mediaTypeOpenMetrics + "; version=" + openMetricsVersion + "; charset=utf-8"
+	case FormatProtobuf:
+		return mediaTypeProtobuf + "; proto=" + protobufProto + "; encoding=" + protobufEncoding
+	case FormatText:
+	}
+
+	return mediaTypeText + "; version=0.0.4; charset=utf-8"
+}
+
+// Negotiate selects the exposition format for an HTTP Accept header. The media type with the
+// highest quality wins, earlier entries win ties, and the text format is the default.
+func Negotiate(accept string) Format {
+	best, bestQ := FormatText, 0.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		var format Format
+
+		switch mediaType {
+		case mediaTypeOpenMetrics:
+			if v, ok := params["version"]; ok && v != openMetricsVersion {
+				continue
+			}
+
+			format = FormatOpenMetrics
+		case mediaTypeProtobuf:
+			if params["proto"] != protobufProto || params["encoding"] != protobufEncoding {
+				continue
+			}
+
+			format = FormatProtobuf
+		case mediaTypeText, "text/*", "*/*":
+			format = FormatText
+		default:
+			continue
+		}
+
+		q := 1.0
+
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,56 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const acceptProtobuf = "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   Format
+	}{
+		{"empty", "", FormatText},
+		{"text", "text/plain;version=0.0.4", FormatText},
+		{"openmetrics", "application/openmetrics-text;version=1.0.0", FormatOpenMetrics},
+		{"openmetrics without version", "application/openmetrics-text", FormatOpenMetrics},
+		{"openmetrics unsupported version", "application/openmetrics-text;version=0.0.1", FormatText},
+		{"protobuf", acceptProtobuf, FormatProtobuf},
+		{"protobuf text encoding", "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=text",
+			FormatText},
+		{"protobuf without proto", "application/vnd.google.protobuf;encoding=delimited", FormatText},
+		{"protobuf other proto", "application/vnd.google.protobuf;proto=foo.Bar;encoding=delimited", FormatText},
+		{"prometheus scrape header",
+			acceptProtobuf + ";q=0.6,application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.4,*/*;q=0.1",
+			FormatProtobuf},
+		{"highest quality wins", "text/plain;q=0.5,application/openmetrics-text;version=1.0.0;q=0.9", FormatOpenMetrics},
+		{"earlier entry wins tie", "text/plain,application/openmetrics-text", FormatText},
+		{"earlier entry wins tie reversed", "application/openmetrics-text, text/plain", FormatOpenMetrics},
+		{"default quality is one", "text/plain;q=0.9, application/openmetrics-text", FormatOpenMetrics},
+		{"zero quality is not acceptable", "application/openmetrics-text;q=0", FormatText},
+		{"wildcard", "*/*", FormatText},
+		{"text wildcard", "text/*", FormatText},
+		{"wildcard loses to openmetrics", "*/*;q=0.1,application/openmetrics-text", FormatOpenMetrics},
+		{"unknown type", "application/json", FormatText},
+		{"invalid quality skipped", "application/openmetrics-text;q=high,text/plain;q=0.1", FormatText},
+		{"malformed entry skipped", ";;;,application/openmetrics-text", FormatOpenMetrics},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Negotiate(tt.accept))
+		})
+	}
+}
+
+func TestFormatContentType(t *testing.T) {
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", FormatText.ContentType())
+	assert.Equal(t, "application/openmetrics-text; version=1.0.0; charset=utf-8", FormatOpenMetrics.ContentType())
+	assert.Equal(t,
+		"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited",
+		FormatProtobuf.ContentType())
+}
@@ -0,0 +1,146 @@
+package prometheus
+
+// The protobuf exposition format is a stream of varint length-delimited
+// io.prometheus.client.MetricFamily messages:
+//
+// https://github.com/prometheus/client_model/blob/master/io/prometheus/client/metrics.proto
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/internal/protowire"
+)
+
+// io.prometheus.client.MetricType values.
+const (
+	protoTypeCounter   = 0
+	protoTypeGauge     = 1
+	protoTypeHistogram = 4
+)
+
+// Field numbers of io.prometheus.client messages.
+const (
+	fieldFamilyName   = 1
+	fieldFamilyHelp   = 2
+	fieldFamilyType   = 3
+	fieldFamilyMetric = 4
+	fieldFamilyUnit   = 5
+
+	fieldMetricLabel     = 1
+	fieldMetricGauge     = 2
+	fieldMetricCounter   = 3
+	fieldMetricHistogram = 7
+
+	fieldLabelName  = 1
+	fieldLabelValue = 2
+
+	fieldValue          = 1
+	fieldCounterCreated = 3
+
+	fieldHistogramCount   = 1
+	fieldHistogramSum     = 2
+	fieldHistogramBucket  = 3
+	fieldHistogramCreated = 15
+
+	fieldBucketCount      = 1
+	fieldBucketUpperBound = 2
+
+	fieldTimestampSeconds = 1
+	fieldTimestampNanos   = 2
+)
+
+func protoTimestamp(t time.Time) protowire.Buffer {
+	var b protowire.Buffer
+
+	b = b.Varint(fieldTimestampSeconds, uint64(t.Unix()))
+
+	return b.Varint(fieldTimestampNanos, uint64(t.Nanosecond()))
+}
+
+func protoMetric(fam *metricFamily, m metric) protowire.Buffer {
+	var b protowire.Buffer
+
+	for _, l := range m.labels {
+		var lb protowire.Buffer
+
+		b = b.Bytes(fieldMetricLabel, lb.String(fieldLabelName, l.name).String(fieldLabelValue, l.value))
+	}
+
+	if fam.typ == metricTypeHistogram {
+		return b.Bytes(fieldMetricHistogram, protoHistogram(m))
+	}
+
+	var value protowire.Buffer
+
+	value = value.Double(fieldValue, m.value)
+
+	if fam.typ == metricTypeCounter {
+		if !m.created.IsZero() {
+			value = value.Bytes(fieldCounterCreated, protoTimestamp(m.created))
+		}
+
+		return b.Bytes(fieldMetricCounter, value)
+	}
+
+	return b.Bytes(fieldMetricGauge, value)
+}
+
+func protoHistogram(m metric) protowire.Buffer {
+	var b protowire.Buffer
+
+	b = b.Varint(fieldHistogramCount, m.histogram.count)
+	b = b.Double(fieldHistogramSum, m.histogram.sum)
+
+	for _, bkt := range m.histogram.buckets {
+		var bb protowire.Buffer
+
+		b = b.Bytes(fieldHistogramBucket, bb.Varint(fieldBucketCount, bkt.count).Double(fieldBucketUpperBound, bkt.upperBound))
+	}
+
+	if !m.created.IsZero() {
+		b = b.Bytes(fieldHistogramCreated, protoTimestamp(m.created))
+	}
+
+	return b
+}
+
+func protoFamily(fam *metricFamily) protowire.Buffer {
+	var b protowire.Buffer
+
+	name, typ := fam.name, protoTypeGauge
+
+	switch fam.typ {
+	case metricTypeCounter:
+		name, typ = name+"_total", protoTypeCounter
+	case metricTypeInfo:
+		name += "_info"
+	case metricTypeHistogram:
+		typ = protoTypeHistogram
+	case metricTypeGauge:
+	}
+
+	b = b.String(fieldFamilyName, name)
+	b = b.String(fieldFamilyHelp, fam.help)
+	b = b.Varint(fieldFamilyType, uint64(typ))
+
+	for _, m := range fam.metrics {
+		b = b.Bytes(fieldFamilyMetric, protoMetric(fam, m))
+	}
+
+	return b.String(fieldFamilyUnit, fam.unit)
+}
+
+// writeProtobufFamily writes a length-delimited MetricFamily message.
+func (w *expWriter) writeProtobufFamily(fam *metricFamily) {
+	if w.err != nil {
+		return
+	}
+
+	msg := protoFamily(fam)
+
+	if _, err := w.w.Write(append(binary.AppendUvarint(nil, uint64(len(msg))), msg...)); err != nil {
+		w.err = fmt.Errorf("unable to write metrics: %w", err)
+	}
+}
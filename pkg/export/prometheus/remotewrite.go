@@ -0,0 +1,119 @@
+package prometheus
+
+// Remote write requests are prometheus.WriteRequest messages, version 1:
+//
+// https://prometheus.io/docs/specs/remote_write_spec/
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/internal/protowire"
+)
+
+// Field numbers of prometheus.WriteRequest messages.
+const (
+	fieldWriteRequestTimeseries = 1
+
+	fieldTimeSeriesLabels  = 1
+	fieldTimeSeriesSamples = 2
+
+	fieldSampleValue     = 1
+	fieldSampleTimestamp = 2
+)
+
+const labelMetricName = "__name__"
+
+// series is a single flattened time series with its metric name as a label.
+type series struct {
+	labels []labelPair
+	value  float64
+}
+
+// flatten converts a metric family into the series a scrape of the text format would produce.
+func flatten(fam *metricFamily) []series {
+	var out []series
+
+	add := func(name string, labels []labelPair, value float64) {
+		ls := append([]labelPair{label(labelMetricName, name)}, labels...)
+		out = append(out, series{labels: ls, value: value})
+	}
+
+	for _, m := range fam.metrics {
+		switch fam.typ {
+		case metricTypeCounter:
+			add(fam.name+"_total", m.labels, m.value)
+		case metricTypeInfo:
+			add(fam.name+"_info", m.labels, m.value)
+		case metricTypeHistogram:
+			for _, b := range m.histogram.buckets {
+				add(fam.name+"_bucket", append(slices.Clone(m.labels), label("le", formatFloat(b.upperBound))),
+					float64(b.count))
+			}
+
+			add(fam.name+"_bucket", append(slices.Clone(m.labels), label("le", "+Inf")), float64(m.histogram.count))
+			add(fam.name+"_sum", m.labels, m.histogram.sum)
+			add(fam.name+"_count", m.labels, float64(m.histogram.count))
+		case metricTypeGauge:
+			add(fam.name, m.labels, m.value)
+		}
+	}
+
+	return out
+}
+
+// WriteRequest encodes all metrics of a snapshot as an uncompressed remote write request, with the
+// snapshot time as sample timestamp. The external labels are added to every series unless the
+// series already has a label of the same name.
+func WriteRequest(snap *snapshot.Snapshot, external map[string]string) ([]byte, error) {
+	for name := range external {
+		if !isValidLabelName(name) {
+			return nil, fmt.Errorf("invalid external label name %q", name)
+		}
+	}
+
+	timestamp := snap.Time.UnixNano() / int64(time.Millisecond)
+
+	var b protowire.Buffer
+
+	for _, fam := range families(snap) {
+		if err := validateFamily(fam); err != nil {
+			return nil, err
+		}
+
+		for _, s := range flatten(fam) {
+			b = b.Bytes(fieldWriteRequestTimeseries, protoTimeSeries(s, external, timestamp))
+		}
+	}
+
+	return b, nil
+}
+
+func protoTimeSeries(s series, external map[string]string, timestamp int64) protowire.Buffer {
+	labels := s.labels
+
+	for name, value := range external {
+		if !slices.ContainsFunc(labels, func(l labelPair) bool { return l.name == name }) {
+			labels = append(labels, label(name, value))
+		}
+	}
+
+	slices.SortFunc(labels, func(a, b labelPair) int { return strings.Compare(a.name, b.name) })
+
+	var b protowire.Buffer
+
+	for _, l := range labels {
+		var lb protowire.Buffer
+
+		b = b.Bytes(fieldTimeSeriesLabels, lb.String(fieldLabelName, l.name).String(fieldLabelValue, l.value))
+	}
+
+	var sample protowire.Buffer
+
+	sample = sample.Double(fieldSampleValue, s.value).Varint(fieldSampleTimestamp, uint64(timestamp))
+
+	return b.Bytes(fieldTimeSeriesSamples, sample)
+}
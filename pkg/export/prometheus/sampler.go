@@ -0,0 +1,97 @@
+package prometheus
+
+import (
+	"math"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+)
+
+var throttledFlagLabels = []struct {
+	flag  uint32
+	label string
+}{
+	{mbox.ThrottledUnderVoltage, "under_voltage"},
+	{mbox.ThrottledFreqCapped, "freq_capped"},
+	{mbox.ThrottledThrottled, "throttled"},
+	{mbox.ThrottledSoftTempLimit, "soft_temp_limit"},
+}
+
+func sampledHistogram(h snapshot.Histogram) *histogram {
+	out := &histogram{count: h.Count, sum: h.Sum}
+	for i, upper := range h.Bounds {
+		out.buckets = append(out.buckets, bucket{upperBound: upper, count: h.Counts[i]})
+	}
+
+	return out
+}
+
+// samplerFamilies converts the readings of the background sampler into metric families. The
+// minimum and maximum cover the samples taken since the previous collection.
+func samplerFamilies(snap *snapshot.Snapshot) []*metricFamily {
+	samples := snap.Samples
+	if samples == nil {
+		return nil
+	}
+
+	count := &metricFamily{name: "rpi_sampler_samples", help: "Number of background samples taken.", typ: metricTypeCounter}
+	count.addCounter(float64(samples.Count), samples.Since)
+
+	errs := &metricFamily{
+		name: "rpi_sampler_errors", help: "Number of background samples with failed readings.", typ: metricTypeCounter,
+	}
+	errs.addCounter(float64(samples.Errors), samples.Since)
+
+	tempHist := &metricFamily{
+		name: "rpi_temperature_celsius_histogram", help: "Sampled temperature of the SoC in degrees celsius.",
+		typ: metricTypeHistogram,
+	}
+	tempHist.addHistogram(sampledHistogram(samples.Temperature.Histogram), samples.Since, label("id", "soc"))
+
+	tempMin := &metricFamily{
+		name: "rpi_sampled_min_temperature_c", help: "Minimum sampled temperature of the SoC since the last scrape.",
+		unit: "c", typ: metricTypeGauge,
+	}
+	tempMax := &metricFamily{
+		name: "rpi_sampled_max_temperature_c", help: "Maximum sampled temperature of the SoC since the last scrape.",
+		unit: "c", typ: metricTypeGauge,
+	}
+
+	if !math.IsNaN(samples.Temperature.Min) {
+		tempMin.add(samples.Temperature.Min, label("id", "soc"))
+		tempMax.add(samples.Temperature.Max, label("id", "soc"))
+	}
+
+	clockHist := &metricFamily{
+		name: "rpi_clock_rate_measured_hz_histogram", help: "Sampled measured clock rate in Hertz.",
+		typ: metricTypeHistogram,
+	}
+	clockMin := &metricFamily{
+		name: "rpi_sampled_min_clock_rate_measured_hz", help: "Minimum sampled clock rate since the last scrape.",
+		unit: "hz", typ: metricTypeGauge,
+	}
+	clockMax := &metricFamily{
+		name: "rpi_sampled_max_clock_rate_measured_hz", help: "Maximum sampled clock rate since the last scrape.",
+		unit: "hz", typ: metricTypeGauge,
+	}
+
+	for id, series := range samples.Clocks {
+		clockHist.addHistogram(sampledHistogram(series.Histogram), samples.Since, label("id", id))
+
+		if !math.IsNaN(series.Min) {
+			clockMin.add(series.Min, label("id", id))
+			clockMax.add(series.Max, label("id", id))
+		}
+	}
+
+	throttled := &metricFamily{
+		name: "rpi_sampled_throttled", help: "Whether a throttled flag was sampled since the last scrape.",
+		typ: metricTypeGauge,
+	}
+
+	for _, f := range throttledFlagLabels {
+		throttled.add(boolValue(samples.Throttled&f.flag != 0), label("flag", f.label))
+	}
+
+	return []*metricFamily{count, errs, tempHist, tempMin, tempMax, clockHist, clockMin, clockMax, throttled}
+}
@@ -0,0 +1,46 @@
+package prometheus
+
+import (
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+// Label is a label of a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single series of the exposition, as a scrape of the text format would produce it,
+// for emitters of protocols without a type system.
+type Sample struct {
+	Name string
+	// Labels are in exposition order.
+	Labels []Label
+	Value  float64
+}
+
+// Samples flattens all metrics of a snapshot into samples. Histograms are flattened into their
+// _bucket, _sum and _count series.
+func Samples(snap *snapshot.Snapshot) []Sample {
+	var out []Sample
+
+	for _, fam := range families(snap) {
+		for _, s := range flatten(fam) {
+			sample := Sample{Value: s.value}
+
+			for _, l := range s.labels {
+				if l.name == labelMetricName {
+					sample.Name = l.value
+
+					continue
+				}
+
+				sample.Labels = append(sample.Labels, Label{Name: l.name, Value: l.value})
+			}
+
+			out = append(out, sample)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,70 @@
+package prometheus
+
+import (
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+// throttleEventFamilyNames maps throttle conditions to the name of their event counter.
+var throttleEventFamilyNames = map[string]string{
+	"under_voltage":   "rpi_undervoltage_events",
+	"freq_capped":     "rpi_freq_capped_events",
+	"throttled":       "rpi_throttling_events",
+	"soft_temp_limit": "rpi_soft_temp_limit_events",
+}
+
+// throttleFamilies converts the throttled state and the throttle event counters into metric
+// families.
+func throttleFamilies(snap *snapshot.Snapshot) []*metricFamily {
+	throttled := &metricFamily{
+		name: "rpi_throttled", help: "Throttled state of the SoC (1: active).", typ: metricTypeGauge,
+	}
+	occurred := &metricFamily{
+		name: "rpi_throttled_occurred",
+		help: "Whether a throttle condition occurred since boot, or since the last poll of the throttle monitor.",
+		typ:  metricTypeGauge,
+	}
+
+	if r := snap.Throttle.State; r.OK() {
+		for _, c := range snapshot.ThrottleConditions {
+			throttled.add(boolValue(r.Value.Flags&c.Active != 0), label("flag", c.Name))
+			occurred.add(boolValue(r.Value.Flags&c.Occurred != 0), label("flag", c.Name))
+		}
+	}
+
+	return append([]*metricFamily{throttled, occurred}, throttleEventFamilies(snap.ThrottleEvents)...)
+}
+
+// throttleEventFamilies converts the throttle event counters into metric families.
+func throttleEventFamilies(events *snapshot.ThrottleEvents) []*metricFamily {
+	if events == nil {
+		return nil
+	}
+
+	seconds := &metricFamily{
+		name: "rpi_throttled_seconds", help: "Time a throttle condition was active in seconds.", unit: "seconds",
+		typ: metricTypeCounter,
+	}
+
+	errs := &metricFamily{
+		name: "rpi_throttle_poll_errors", help: "Number of failed polls of the throttled state.",
+		typ: metricTypeCounter,
+	}
+	errs.addCounter(float64(events.Errors), events.Since)
+
+	fams := []*metricFamily{seconds, errs}
+
+	for _, c := range snapshot.ThrottleConditions {
+		fam := &metricFamily{
+			name: throttleEventFamilyNames[c.Name],
+			help: "Number of times the " + c.Name + " condition occurred.",
+			typ:  metricTypeCounter,
+		}
+		fam.addCounter(float64(events.Events[c.Name]), events.Since)
+
+		seconds.addCounter(events.Seconds[c.Name], events.Since, label("flag", c.Name))
+
+		fams = append(fams, fam)
+	}
+
+	return fams
+}
@@ -5,300 +5,201 @@ found in the Mailbox Property Interface of a Raspberry Pi.
 package prometheus
 
 // NB: We could implement a Prometheus Collector, but this simple exporter allows us to avoid
-// dependencies. The exposition format is formalized here:
+// dependencies. The exposition formats are formalized here:
 //
 // https://prometheus.io/docs/instrumenting/exposition_formats/
+// https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md
+// https://github.com/prometheus/client_model/blob/master/io/prometheus/client/metrics.proto
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 
-	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
 )
 
-const (
-	metricTypeGauge = "gauge"
-)
-
-const (
-	fahrenheitFactorNumerator   = 9
-	fahrenheitFactorDenominator = 5
-	fahrenheitOffset            = 32
-)
-
-var voltageLabelsByID = map[mbox.VoltageID]string{
-	mbox.VoltageIDCore:   "core",
-	mbox.VoltageIDSDRAMC: "sdram_c",
-	mbox.VoltageIDSDRAMI: "sdram_i",
-	mbox.VoltageIDSDRAMP: "sdram_p",
-}
-
-var powerLabelsByID = map[mbox.PowerDeviceID]string{
-	mbox.PowerDeviceIDSDCard: "sd_card",
-	mbox.PowerDeviceIDUART0:  "uart0",
-	mbox.PowerDeviceIDUART1:  "uart1",
-	mbox.PowerDeviceIDUSBHCD: "usb_hcd",
-	mbox.PowerDeviceIDI2C0:   "i2c0",
-	mbox.PowerDeviceIDI2C1:   "i2c1",
-	mbox.PowerDeviceIDI2C2:   "i2c2",
-	mbox.PowerDeviceIDSPI:    "spi",
-	mbox.PowerDeviceIDCCP2TX: "ccp2tx",
-}
-
-var clockLabelsByID = map[mbox.ClockID]string{
-	mbox.ClockIDEMMC:     "emmc",
-	mbox.ClockIDUART:     "uart",
-	mbox.ClockIDARM:      "arm",
-	mbox.ClockIDCore:     "core",
-	mbox.ClockIDV3D:      "v3d",
-	mbox.ClockIDH264:     "h264",
-	mbox.ClockIDISP:      "isp",
-	mbox.ClockIDSDRAM:    "sdram",
-	mbox.ClockIDPixel:    "pixel",
-	mbox.ClockIDPWM:      "pwm",
-	mbox.ClockIDHEVC:     "hevc",
-	mbox.ClockIDEMMC2:    "emmc2",
-	mbox.ClockIDM2MC:     "m2mc",
-	mbox.ClockIDPixelBVB: "pixel_bvb",
-}
-
-func formatTemp(t float32) string  { return fmt.Sprintf("%.03f", t) }
-func formatVolts(v float32) string { return fmt.Sprintf("%.06f", v) }
-
-func formatBool(b bool) string {
-	if b {
-		return "1"
-	}
-
-	return "0"
-}
-
 type expWriter struct {
 	w      io.Writer
-	name   string
-	labels []string
+	format Format
+	err    error
 }
 
-// Write all metrics in Prometheus text-based exposition format.
+// Write collects a snapshot and writes all metrics in Prometheus text-based exposition format.
+// Readings that could not be collected are omitted from the output and returned as an error after
+// the remaining metrics have been written.
 func Write(w io.Writer) error {
-	ew := &expWriter{w: w}
+	snap, err := snapshot.Collect()
+	if err != nil {
+		return err
+	}
 
-	return ew.write()
+	return errors.Join(Render(w, snap), snap.Err())
 }
 
-func (w *expWriter) writeHeaderGauge(name, help string, labels ...string) {
-	w.name = name
-	w.labels = labels
-	fmt.Fprintf(w.w, "# HELP %s %s\n", name, help)
-	fmt.Fprintf(w.w, "# TYPE %s %v\n", name, "gauge")
+// Render writes all metrics of a snapshot in Prometheus text-based exposition format. Readings
+// that failed are omitted.
+func Render(w io.Writer, snap *snapshot.Snapshot) error {
+	return RenderFormat(w, snap, FormatText)
 }
 
-func (w *expWriter) writeSample(val interface{}, labels ...string) {
-	if len(labels) != len(w.labels) {
-		panic("developer error: incorrect metrics label count")
-	}
-
-	fmt.Fprint(w.w, w.name)
-
-	if len(w.labels) > 0 {
-		fmt.Fprintf(w.w, "{")
-
-		for i, key := range w.labels {
-			if i > 0 {
-				fmt.Fprintf(w.w, ",")
-			}
+// RenderFormat writes all metrics of a snapshot in the given exposition format. Readings that
+// failed are omitted.
+func RenderFormat(w io.Writer, snap *snapshot.Snapshot, format Format) error {
+	return renderFamilies(w, families(snap), format)
+}
 
-			fmt.Fprintf(w.w, "%s=\"%s\"", key, labels[i])
-		}
+// textfileExcludedPrefixes are the families node_exporter exports itself, which would collide in
+// its textfile collector.
+var textfileExcludedPrefixes = []string{"process_", "go_"}
 
-		fmt.Fprintf(w.w, "}")
-	}
+// RenderTextfile writes all metrics of a snapshot in Prometheus text-based exposition format for
+// the node_exporter textfile collector. The process and Go runtime families are omitted.
+func RenderTextfile(w io.Writer, snap *snapshot.Snapshot) error {
+	fams := slices.DeleteFunc(families(snap), func(fam *metricFamily) bool {
+		return slices.ContainsFunc(textfileExcludedPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(fam.name, prefix)
+		})
+	})
 
-	fmt.Fprintf(w.w, " %v\n", val)
+	return renderFamilies(w, fams, FormatText)
 }
 
-func (w *expWriter) write() error {
-	mboxOpen, err := mbox.Open()
-	if err != nil {
-		return fmt.Errorf("unable to open mbox: %w", err)
-	}
-
-	defer mboxOpen.Close()
+func renderFamilies(w io.Writer, fams []*metricFamily, format Format) error {
+	ew := &expWriter{w: w, format: format}
 
-	if err := w.writeHardware(mboxOpen); err != nil {
-		return err
+	for _, fam := range fams {
+		ew.writeFamily(fam)
 	}
 
-	if err := w.writePower(mboxOpen); err != nil {
-		return err
+	if format == FormatOpenMetrics {
+		ew.printf("# EOF\n")
 	}
 
-	if err := w.writeClocks(mboxOpen); err != nil {
-		return err
-	}
+	return ew.err
+}
 
-	if err := w.writeTemperatures(mboxOpen); err != nil {
-		return err
+func (w *expWriter) printf(format string, a ...interface{}) {
+	if w.err != nil {
+		return
 	}
 
-	if err := w.writeVoltages(mboxOpen); err != nil {
-		return err
+	if _, err := fmt.Fprintf(w.w, format, a...); err != nil {
+		w.err = fmt.Errorf("unable to write metrics: %w", err)
 	}
-
-	return nil
 }
 
-func (w *expWriter) writeHardware(mboxOpen *mbox.Mailbox) error {
-	w.writeHeaderGauge("rpi_vc_revision", "Firmware revision of the VideoCore device.", metricTypeGauge)
-
-	rev, err := mboxOpen.GetFirmwareRevision()
-	if err != nil {
-		return fmt.Errorf("unable to get firmware revision: %w", err)
+func (w *expWriter) writeFamily(fam *metricFamily) {
+	if len(fam.metrics) == 0 || w.err != nil {
+		return
 	}
 
-	w.writeSample(rev)
+	if err := validateFamily(fam); err != nil {
+		w.err = err
 
-	w.writeHeaderGauge("rpi_board_model", "Board model.", metricTypeGauge)
-
-	model, err := mboxOpen.GetBoardModel()
-	if err != nil {
-		return fmt.Errorf("unable to get board model: %w", err)
+		return
 	}
 
-	w.writeSample(model)
+	switch w.format {
+	case FormatOpenMetrics:
+		w.writeOpenMetricsFamily(fam)
 
-	w.writeHeaderGauge("rpi_board_revision", "Board revision.", metricTypeGauge)
+		return
+	case FormatProtobuf:
+		w.writeProtobufFamily(fam)
 
-	rev, err = mboxOpen.GetBoardRevision()
-	if err != nil {
-		return fmt.Errorf("unable to get board revision: %w", err)
+		return
+	case FormatText:
 	}
 
-	w.writeSample(rev)
-
-	return nil
-}
+	name, typ := fam.name, fam.typ
 
-func (w *expWriter) writePower(mboxOpen *mbox.Mailbox) error {
-	w.writeHeaderGauge("rpi_power_state", "Component power state (0: off, 1: on, 2: missing).", metricTypeGauge, "id")
-
-	for id, label := range powerLabelsByID {
-		state, err := mboxOpen.GetPowerState(id)
-		if err != nil {
-			return fmt.Errorf("unable to get power state: %w", err)
-		}
-
-		w.writeSample(state, label)
+	switch fam.typ {
+	case metricTypeCounter:
+		name += "_total"
+	case metricTypeInfo:
+		name, typ = name+"_info", metricTypeGauge
+	case metricTypeGauge, metricTypeHistogram:
 	}
 
-	return nil
-}
+	w.printf("# HELP %s %s\n", name, helpEscaper.Replace(fam.help))
+	w.printf("# TYPE %s %s\n", name, typ)
 
-func (w *expWriter) writeClocks(mboxOpen *mbox.Mailbox) error {
-	w.writeHeaderGauge("rpi_clock_rate_hz", "Clock rate in Hertz.", metricTypeGauge, "id")
+	for _, m := range fam.metrics {
+		if fam.typ == metricTypeHistogram {
+			w.writeHistogram(fam.name, m)
 
-	for id, label := range clockLabelsByID {
-		rate, err := mboxOpen.GetClockRate(id)
-		if err != nil {
-			return fmt.Errorf("unable to get clock rate: %w", err)
+			continue
 		}
 
-		w.writeSample(rate, label)
+		w.writeSample(name, m.labels, m.value)
 	}
+}
 
-	w.writeHeaderGauge("rpi_clock_rate_measured_hz", "Measured clock rate in Hertz.", metricTypeGauge, "id")
-
-	for id, label := range clockLabelsByID {
-		rate, err := mboxOpen.GetClockRateMeasured(id)
-		if err != nil {
-			return fmt.Errorf("unable to get measured clock rate: %w", err)
-		}
+func (w *expWriter) writeOpenMetricsFamily(fam *metricFamily) {
+	w.printf("# TYPE %s %s\n", fam.name, fam.typ)
 
-		w.writeSample(rate, label)
+	if fam.unit != "" {
+		w.printf("# UNIT %s %s\n", fam.name, fam.unit)
 	}
 
-	w.writeHeaderGauge("rpi_turbo", "Turbo state.", metricTypeGauge)
+	w.printf("# HELP %s %s\n", fam.name, openMetricsHelpEscaper.Replace(fam.help))
 
-	turbo, err := mboxOpen.GetTurbo()
-	if err != nil {
-		return fmt.Errorf("unable to get turbo: %w", err)
+	for _, m := range fam.metrics {
+		switch fam.typ {
+		case metricTypeCounter:
+			w.writeSample(fam.name+"_total", m.labels, m.value)
+			w.writeCreated(fam.name, m)
+		case metricTypeHistogram:
+			w.writeHistogram(fam.name, m)
+			w.writeCreated(fam.name, m)
+		case metricTypeInfo:
+			w.writeSample(fam.name+"_info", m.labels, m.value)
+		case metricTypeGauge:
+			w.writeSample(fam.name, m.labels, m.value)
+		}
 	}
-
-	w.writeSample(formatBool(turbo))
-
-	return nil
 }
 
-func (w *expWriter) writeTemperatures(mboxOpen *mbox.Mailbox) error {
-	w.writeHeaderGauge("rpi_temperature_c", "Temperature of the SoC in degrees celsius.", metricTypeGauge, "id")
-
-	temp, err := mboxOpen.GetTemperature()
-	if err != nil {
-		return fmt.Errorf("unable to get temperature: %w", err)
+// writeCreated writes the OpenMetrics _created sample of a counter or histogram.
+func (w *expWriter) writeCreated(name string, m metric) {
+	if !m.created.IsZero() {
+		w.writeSample(name+"_created", m.labels, unixSeconds(m.created))
 	}
-
-	w.writeSample(formatTemp(temp), "soc")
-
-	w.writeHeaderGauge("rpi_temperature_f", "Temperature of the SoC in degrees fahrenheit.", metricTypeGauge, "id")
-	w.writeSample(formatTemp(temp*fahrenheitFactorNumerator/fahrenheitFactorDenominator+fahrenheitOffset), "soc")
-
-	w.writeHeaderGauge(
-		"rpi_max_temperature_c",
-		"Maximum temperature of the SoC in degrees celsius.",
-		metricTypeGauge,
-		"id",
-	)
-
-	maxTemp, err := mboxOpen.GetMaxTemperature()
-	if err != nil {
-		return fmt.Errorf("unable to get maximum temperature: %w", err)
-	}
-
-	w.writeSample(formatTemp(maxTemp), "soc")
-
-	w.writeHeaderGauge(
-		"rpi_max_temperature_f",
-		"Maximum temperature of the SoC in degrees fahrenheit.",
-		metricTypeGauge,
-		"id")
-	w.writeSample(formatTemp(temp*fahrenheitFactorNumerator/fahrenheitFactorDenominator+fahrenheitOffset), "soc")
-
-	return nil
 }
 
-func (w *expWriter) writeVoltages(mboxOpen *mbox.Mailbox) error {
-	w.writeHeaderGauge("rpi_voltage", "Current component voltage.", metricTypeGauge, "id")
+// writeHistogram writes the cumulative buckets, sum and count samples of a histogram.
+func (w *expWriter) writeHistogram(name string, m metric) {
+	h := m.histogram
 
-	for id, label := range voltageLabelsByID {
-		volts, err := mboxOpen.GetVoltage(id)
-		if err != nil {
-			return fmt.Errorf("unable to get voltage: %w", err)
-		}
-
-		w.writeSample(formatVolts(volts), label)
+	for _, b := range h.buckets {
+		w.writeSample(name+"_bucket", append(slices.Clone(m.labels), label("le", formatFloat(b.upperBound))),
+			float64(b.count))
 	}
 
-	w.writeHeaderGauge("rpi_voltage_min", "Minimum supported component voltage.", metricTypeGauge, "id")
+	w.writeSample(name+"_bucket", append(slices.Clone(m.labels), label("le", "+Inf")), float64(h.count))
+	w.writeSample(name+"_sum", m.labels, h.sum)
+	w.writeSample(name+"_count", m.labels, float64(h.count))
+}
 
-	for id, label := range voltageLabelsByID {
-		volts, err := mboxOpen.GetMinVoltage(id)
-		if err != nil {
-			return fmt.Errorf("unable to get minimum voltage: %w", err)
-		}
+func (w *expWriter) writeSample(name string, labels []labelPair, val float64) {
+	w.printf("%s", name)
 
-		w.writeSample(formatVolts(volts), label)
-	}
+	if len(labels) > 0 {
+		w.printf("{")
 
-	w.writeHeaderGauge("rpi_voltage_max", "Maximum supported component voltage.", metricTypeGauge, "id")
+		for i, l := range labels {
+			if i > 0 {
+				w.printf(",")
+			}
 
-	for id, label := range voltageLabelsByID {
-		volts, err := mboxOpen.GetMaxVoltage(id)
-		if err != nil {
-			return fmt.Errorf("unable to get max voltage: %w", err)
+			w.printf("%s=\"%s\"", l.name, labelValueEscaper.Replace(l.value))
 		}
 
-		w.writeSample(formatVolts(volts), label)
+		w.printf("}")
 	}
 
-	return nil
+	w.printf(" %s\n", formatFloat(val))
 }
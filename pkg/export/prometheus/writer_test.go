@@ -0,0 +1,162 @@
+package prometheus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCreated = time.Unix(1700000000, 500000000)
+
+func writeFamilies(t *testing.T, format Format, fams ...*metricFamily) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	ew := &expWriter{w: &buf, format: format}
+	for _, fam := range fams {
+		ew.writeFamily(fam)
+	}
+
+	require.NoError(t, ew.err)
+
+	return buf.String()
+}
+
+func TestOpenMetricsGaugeWithUnit(t *testing.T) {
+	fam := &metricFamily{
+		name: "rpi_temperature_celsius", help: "Temperature of the SoC.", unit: "celsius", typ: metricTypeGauge,
+	}
+	fam.add(48.3)
+
+	assert.Equal(t, `# TYPE rpi_temperature_celsius gauge
+# UNIT rpi_temperature_celsius celsius
+# HELP rpi_temperature_celsius Temperature of the SoC.
+rpi_temperature_celsius 48.3
+`, writeFamilies(t, FormatOpenMetrics, fam))
+
+	assert.Equal(t, `# HELP rpi_temperature_celsius Temperature of the SoC.
+# TYPE rpi_temperature_celsius gauge
+rpi_temperature_celsius 48.3
+`, writeFamilies(t, FormatText, fam))
+}
+
+func TestOpenMetricsInfo(t *testing.T) {
+	fam := &metricFamily{name: "rpi_board", help: "Board information.", typ: metricTypeInfo}
+	fam.add(1, label("model", "0"), label("revision", "a03111"))
+
+	assert.Equal(t, `# TYPE rpi_board info
+# HELP rpi_board Board information.
+rpi_board_info{model="0",revision="a03111"} 1
+`, writeFamilies(t, FormatOpenMetrics, fam))
+
+	// The text format has no info type, info metrics are gauges with the _info suffix.
+	assert.Equal(t, `# HELP rpi_board_info Board information.
+# TYPE rpi_board_info gauge
+rpi_board_info{model="0",revision="a03111"} 1
+`, writeFamilies(t, FormatText, fam))
+}
+
+func TestOpenMetricsCounter(t *testing.T) {
+	fam := &metricFamily{name: "rpi_undervoltage_events", help: "Under-voltage events.", typ: metricTypeCounter}
+	fam.addCounter(3, testCreated)
+
+	assert.Equal(t, `# TYPE rpi_undervoltage_events counter
+# HELP rpi_undervoltage_events Under-voltage events.
+rpi_undervoltage_events_total 3
+rpi_undervoltage_events_created 1700000000.5
+`, writeFamilies(t, FormatOpenMetrics, fam))
+
+	// The text format has no _created samples and names the family after the sample.
+	assert.Equal(t, `# HELP rpi_undervoltage_events_total Under-voltage events.
+# TYPE rpi_undervoltage_events_total counter
+rpi_undervoltage_events_total 3
+`, writeFamilies(t, FormatText, fam))
+}
+
+func TestOpenMetricsHistogram(t *testing.T) {
+	fam := &metricFamily{
+		name: "rpi_temperature_celsius_histogram", help: "Sampled temperature.", typ: metricTypeHistogram,
+	}
+	fam.addHistogram(&histogram{count: 3, sum: 150, buckets: []bucket{{40, 0}, {50, 2}}}, testCreated)
+
+	assert.Equal(t, `# TYPE rpi_temperature_celsius_histogram histogram
+# HELP rpi_temperature_celsius_histogram Sampled temperature.
+rpi_temperature_celsius_histogram_bucket{le="40"} 0
+rpi_temperature_celsius_histogram_bucket{le="50"} 2
+rpi_temperature_celsius_histogram_bucket{le="+Inf"} 3
+rpi_temperature_celsius_histogram_sum 150
+rpi_temperature_celsius_histogram_count 3
+rpi_temperature_celsius_histogram_created 1700000000.5
+`, writeFamilies(t, FormatOpenMetrics, fam))
+}
+
+func TestOpenMetricsHelpEscaping(t *testing.T) {
+	fam := &metricFamily{name: "rpi_test", help: "Quote \" backslash \\ newline \n.", typ: metricTypeGauge}
+	fam.add(1)
+
+	// OpenMetrics escapes quotes in HELP, the text format does not.
+	assert.Contains(t, writeFamilies(t, FormatOpenMetrics, fam), `# HELP rpi_test Quote \" backslash \\ newline \n.`)
+	assert.Contains(t, writeFamilies(t, FormatText, fam), `# HELP rpi_test Quote " backslash \\ newline \n.`)
+}
+
+func TestOpenMetricsEOF(t *testing.T) {
+	snap := &snapshot.Snapshot{Filter: snapshot.Filter{}}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, RenderFormat(&buf, snap, FormatOpenMetrics))
+	assert.Equal(t, "# EOF\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, RenderFormat(&buf, snap, FormatText))
+	assert.Empty(t, buf.String())
+}
+
+func TestEmptyFamiliesOmitted(t *testing.T) {
+	fam := &metricFamily{name: "rpi_board", help: "Board information.", typ: metricTypeInfo}
+
+	for _, format := range []Format{FormatText, FormatOpenMetrics, FormatProtobuf} {
+		assert.Empty(t, writeFamilies(t, format, fam))
+	}
+}
+
+func TestInvalidFamilyRejected(t *testing.T) {
+	fam := &metricFamily{name: "rpi_temperature", help: "x", unit: "celsius", typ: metricTypeGauge}
+	fam.add(1)
+
+	var buf bytes.Buffer
+
+	ew := &expWriter{w: &buf, format: FormatOpenMetrics}
+	ew.writeFamily(fam)
+
+	require.Error(t, ew.err)
+	assert.True(t, strings.Contains(ew.err.Error(), "unit"))
+}
+
+func TestRenderTextfileOmitsProcessFamilies(t *testing.T) {
+	filter, err := snapshot.NewFilter(snapshot.SectionExporter)
+	require.NoError(t, err)
+
+	snap := snapshot.CollectFrom(nil, filter)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, RenderTextfile(&buf, snap))
+
+	out := buf.String()
+	assert.Contains(t, out, "rpi_exporter_build_info{")
+	assert.Contains(t, out, "rpi_exporter_mailbox_requests_total ")
+	assert.Contains(t, out, "rpi_exporter_cache_hits_total ")
+	assert.NotContains(t, out, "\nprocess_")
+	assert.NotContains(t, out, "\ngo_")
+
+	buf.Reset()
+	require.NoError(t, Render(&buf, snap))
+	assert.Contains(t, buf.String(), "\ngo_goroutines ")
+}
@@ -0,0 +1,189 @@
+/*
+Package pushgateway pushes Prometheus metrics to a Pushgateway, for hosts that Prometheus cannot
+scrape directly. The API is documented here:
+
+https://github.com/prometheus/pushgateway#api
+*/
+package pushgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+	maxErrorBody    = 512
+)
+
+// errUnrecoverable marks requests the Pushgateway rejected permanently. Retrying them is pointless.
+var errUnrecoverable = errors.New("request rejected")
+
+// Pusher pushes metrics to a single grouping key of a Pushgateway.
+type Pusher struct {
+	// Client is the HTTP client used for requests.
+	Client *http.Client
+	// Username and Password enable basic auth if Username is set.
+	Username string
+	Password string
+	// Retries is the number of retries of a failed request.
+	Retries int
+	// Backoff is the delay before the first retry. It doubles with every retry.
+	Backoff time.Duration
+	// Timeout limits each attempt of a request. Zero means no limit.
+	Timeout time.Duration
+
+	url string
+}
+
+// New returns a pusher for the group identified by job and grouping labels on the Pushgateway at
+// baseURL.
+func New(baseURL, job string, grouping map[string]string) (*Pusher, error) {
+	if job == "" {
+		return nil, errors.New("job must not be empty")
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pushgateway url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid pushgateway url %q: scheme must be http or https", baseURL)
+	}
+
+	path := strings.TrimSuffix(u.EscapedPath(), "/") + "/metrics/" + encodeComponent("job", job)
+
+	names := make([]string, 0, len(grouping))
+	for name := range grouping {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		if name == "" || name == "job" {
+			return nil, fmt.Errorf("invalid grouping label name %q", name)
+		}
+
+		path += "/" + encodeComponent(name, grouping[name])
+	}
+
+	u.Path, u.RawPath, u.RawQuery, u.Fragment = "", "", "", ""
+
+	return &Pusher{Client: http.DefaultClient, url: u.String() + path}, nil
+}
+
+// encodeComponent encodes a grouping label as a URL path component, using the base64 form for
+// values the plain form cannot represent.
+func encodeComponent(name, value string) string {
+	if value == "" || strings.Contains(value, "/") {
+		return name + "@base64/" + encodeBase64(value)
+	}
+
+	return url.PathEscape(name) + "/" + url.PathEscape(value)
+}
+
+func encodeBase64(value string) string {
+	if value == "" {
+		return "="
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// URL returns the URL of the group.
+func (p *Pusher) URL() string {
+	return p.url
+}
+
+// Push replaces all metrics of the group with body, a text exposition, retrying on failure.
+func (p *Pusher) Push(ctx context.Context, body []byte) error {
+	return p.retry(ctx, http.MethodPut, body)
+}
+
+// Add replaces only the metrics of the group with the same names as those in body, retrying on
+// failure.
+func (p *Pusher) Add(ctx context.Context, body []byte) error {
+	return p.retry(ctx, http.MethodPost, body)
+}
+
+// Delete deletes all metrics of the group, retrying on failure.
+func (p *Pusher) Delete(ctx context.Context) error {
+	return p.retry(ctx, http.MethodDelete, nil)
+}
+
+// retry sends a request until it succeeds, fails permanently or runs out of retries. Transport
+// errors, 5xx and 429 responses are retried.
+func (p *Pusher) retry(ctx context.Context, method string, body []byte) error {
+	backoff := p.Backoff
+
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		err = p.do(ctx, method, body)
+		if err == nil || errors.Is(err, errUnrecoverable) || attempt >= p.Retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+func (p *Pusher) do(ctx context.Context, method string, body []byte) error {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentTypeText)
+	}
+
+	if p.Username != "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to %s metrics: %w", strings.ToLower(method), err)
+	}
+
+	defer resp.Body.Close()
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	err = fmt.Errorf("unexpected status %s from pushgateway: %s", resp.Status, strings.TrimSpace(string(msg)))
+
+	if resp.StatusCode/100 != 5 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", errUnrecoverable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,288 @@
+package pushgateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBody = "rpi_temperature_c{id=\"soc\"} 48.3\n"
+
+// request is a request received by the Pushgateway stand-in.
+type request struct {
+	method      string
+	path        string
+	contentType string
+	body        string
+	username    string
+	password    string
+}
+
+// gateway is a Pushgateway stand-in that records requests and answers with the queued statuses,
+// then with 202 Accepted.
+type gateway struct {
+	mu       sync.Mutex
+	requests []request
+	statuses []int
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	username, password, _ := r.BasicAuth()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.requests = append(g.requests, request{
+		method:      r.Method,
+		path:        r.URL.EscapedPath(),
+		contentType: r.Header.Get("Content-Type"),
+		body:        string(body),
+		username:    username,
+		password:    password,
+	})
+
+	status := http.StatusAccepted
+	if len(g.statuses) > 0 {
+		status, g.statuses = g.statuses[0], g.statuses[1:]
+	}
+
+	w.WriteHeader(status)
+}
+
+func (g *gateway) received() []request {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return append([]request{}, g.requests...)
+}
+
+func newTestPusher(t *testing.T, g *gateway, grouping map[string]string) *Pusher {
+	t.Helper()
+
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+
+	p, err := New(srv.URL+"/prefix/", "rpi_exporter", grouping)
+	require.NoError(t, err)
+
+	p.Client = srv.Client()
+	p.Backoff = time.Millisecond
+
+	return p
+}
+
+func TestGroupingKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      string
+		grouping map[string]string
+		want     string
+	}{
+		{"plain", "rpi", map[string]string{"instance": "pi1", "site": "ams1"},
+			"/metrics/job/rpi/instance/pi1/site/ams1"},
+		{"slash in value", "rpi", map[string]string{"instance": "rack/1"},
+			"/metrics/job/rpi/instance@base64/cmFjay8x"},
+		{"slash in job", "a/b", nil, "/metrics/job@base64/YS9i"},
+		{"empty value", "rpi", map[string]string{"site": ""}, "/metrics/job/rpi/site@base64/="},
+		{"escaped value", "rpi", map[string]string{"instance": "pi 1?"}, "/metrics/job/rpi/instance/pi%201%3F"},
+		{"unpadded base64", "rpi", map[string]string{"instance": "/x"}, "/metrics/job/rpi/instance@base64/L3g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New("http://gateway:9091", tt.job, tt.grouping)
+			require.NoError(t, err)
+			assert.Equal(t, "http://gateway:9091"+tt.want, p.URL())
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		url      string
+		job      string
+		grouping map[string]string
+	}{
+		{"http://gateway:9091", "", nil},
+		{"ftp://gateway", "rpi", nil},
+		{"://", "rpi", nil},
+		{"http://gateway:9091", "rpi", map[string]string{"job": "other"}},
+		{"http://gateway:9091", "rpi", map[string]string{"": "value"}},
+	} {
+		_, err := New(tt.url, tt.job, tt.grouping)
+		assert.Error(t, err, tt)
+	}
+}
+
+func TestPushMethods(t *testing.T) {
+	g := &gateway{}
+	p := newTestPusher(t, g, map[string]string{"instance": "a/b"})
+
+	require.NoError(t, p.Push(context.Background(), []byte(testBody)))
+	require.NoError(t, p.Add(context.Background(), []byte(testBody)))
+	require.NoError(t, p.Delete(context.Background()))
+
+	path := "/prefix/metrics/job/rpi_exporter/instance@base64/YS9i"
+	assert.Equal(t, []request{
+		{method: http.MethodPut, path: path, contentType: contentTypeText, body: testBody},
+		{method: http.MethodPost, path: path, contentType: contentTypeText, body: testBody},
+		{method: http.MethodDelete, path: path},
+	}, g.received())
+}
+
+func TestPushBasicAuth(t *testing.T) {
+	g := &gateway{}
+	p := newTestPusher(t, g, nil)
+	p.Username, p.Password = "pi", "s3cret"
+
+	require.NoError(t, p.Push(context.Background(), []byte(testBody)))
+
+	reqs := g.received()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "pi", reqs[0].username)
+	assert.Equal(t, "s3cret", reqs[0].password)
+}
+
+func TestPushRetries(t *testing.T) {
+	g := &gateway{statuses: []int{http.StatusServiceUnavailable, http.StatusInternalServerError}}
+	p := newTestPusher(t, g, nil)
+	p.Retries = 2
+
+	require.NoError(t, p.Push(context.Background(), []byte(testBody)))
+	assert.Len(t, g.received(), 3)
+}
+
+func TestPushRetriesExhausted(t *testing.T) {
+	unavailable := http.StatusServiceUnavailable
+	g := &gateway{statuses: []int{unavailable, unavailable, unavailable}}
+	p := newTestPusher(t, g, nil)
+	p.Retries = 1
+
+	err := p.Push(context.Background(), []byte(testBody))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503 Service Unavailable")
+	assert.Len(t, g.received(), 2)
+}
+
+func TestPushRetriedStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		attempts int
+	}{
+		{http.StatusBadRequest, 1},
+		{http.StatusUnauthorized, 1},
+		{http.StatusForbidden, 1},
+		{http.StatusNotFound, 1},
+		{http.StatusTooManyRequests, 2},
+		{http.StatusInternalServerError, 2},
+		{http.StatusBadGateway, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			g := &gateway{statuses: []int{tt.status, tt.status, tt.status}}
+			p := newTestPusher(t, g, nil)
+			p.Retries = 1
+
+			err := p.Push(context.Background(), []byte(testBody))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), http.StatusText(tt.status))
+			assert.Len(t, g.received(), tt.attempts)
+		})
+	}
+}
+
+func TestPushRetriesTransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	p, err := New(srv.URL, "rpi_exporter", nil)
+	require.NoError(t, err)
+
+	p.Retries = 2
+	p.Backoff = time.Millisecond
+
+	start := time.Now()
+
+	require.Error(t, p.Push(context.Background(), []byte(testBody)))
+	// Both retries waited for their backoff.
+	assert.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond)
+}
+
+func TestPushTimeoutPerAttempt(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		first := attempts == 1
+		mu.Unlock()
+
+		// The first attempt hangs until the test ends.
+		if first {
+			<-release
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	p, err := New(srv.URL, "rpi_exporter", nil)
+	require.NoError(t, err)
+
+	p.Retries = 1
+	p.Backoff = 50 * time.Millisecond
+	p.Timeout = 50 * time.Millisecond
+
+	// The backoff does not count towards the timeout, so the retry still runs.
+	require.NoError(t, p.Push(context.Background(), []byte(testBody)))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, 2, attempts)
+}
+
+func TestPushBackoffDoubles(t *testing.T) {
+	g := &gateway{statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}}
+	p := newTestPusher(t, g, nil)
+	p.Retries = 3
+	p.Backoff = 20 * time.Millisecond
+
+	start := time.Now()
+
+	require.NoError(t, p.Push(context.Background(), []byte(testBody)))
+	// The retries wait 20ms, 40ms and 80ms.
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+	assert.Len(t, g.received(), 4)
+}
+
+func TestPushBackoffCanceled(t *testing.T) {
+	g := &gateway{statuses: []int{http.StatusBadGateway}}
+	p := newTestPusher(t, g, nil)
+	p.Retries = 1
+	p.Backoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := p.Push(ctx, []byte(testBody))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, g.received(), 1)
+}
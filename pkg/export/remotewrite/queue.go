@@ -0,0 +1,173 @@
+package remotewrite
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	queueFileSuffix = ".rw"
+	queueSeqDigits  = 20
+	queueFileMode   = 0o600
+	queueDirMode    = 0o700
+)
+
+// Queue is a write-ahead buffer of compressed remote write requests, stored as one file per request
+// in a directory so that requests survive restarts and are replayed in the order they were added.
+type Queue struct {
+	dir        string
+	maxEntries int
+
+	mu   sync.Mutex
+	seqs []uint64
+	next uint64
+}
+
+// OpenQueue opens the queue in dir, creating the directory if needed. If maxEntries is positive,
+// the oldest entries are dropped when the queue grows beyond it.
+func OpenQueue(dir string, maxEntries int) (*Queue, error) {
+	if err := os.MkdirAll(dir, queueDirMode); err != nil {
+		return nil, fmt.Errorf("unable to create queue directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read queue directory: %w", err)
+	}
+
+	q := &Queue{dir: dir, maxEntries: maxEntries}
+
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), queueFileSuffix)
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		q.seqs = append(q.seqs, seq)
+		q.next = max(q.next, seq+1)
+	}
+
+	slices.Sort(q.seqs)
+
+	return q, nil
+}
+
+func (q *Queue) path(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%0*d%s", queueSeqDigits, seq, queueFileSuffix))
+}
+
+// Len returns the number of queued requests.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.seqs)
+}
+
+// Append adds a request to the end of the queue. The file is synced before Append returns. It
+// returns the number of old entries dropped to stay within the size limit.
+func (q *Queue) Append(data []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	seq := q.next
+
+	if err := writeFileSync(q.path(seq), data); err != nil {
+		return 0, err
+	}
+
+	q.next++
+	q.seqs = append(q.seqs, seq)
+
+	dropped := 0
+
+	for q.maxEntries > 0 && len(q.seqs) > q.maxEntries {
+		if err := q.remove(q.seqs[0]); err != nil {
+			return dropped, err
+		}
+
+		dropped++
+	}
+
+	return dropped, nil
+}
+
+// writeFileSync writes data to a temporary file, syncs it and renames it into place, so a crash
+// never leaves a partial entry in the queue.
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, queueFileMode)
+	if err != nil {
+		return fmt.Errorf("unable to create queue entry: %w", err)
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("unable to write queue entry: %w", err)
+	}
+
+	return nil
+}
+
+// Peek returns the oldest request and its sequence number. ok is false if the queue is empty.
+func (q *Queue) Peek() (seq uint64, data []byte, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.seqs) == 0 {
+		return 0, nil, false, nil
+	}
+
+	seq = q.seqs[0]
+
+	data, err = os.ReadFile(q.path(seq))
+	if err != nil {
+		return seq, nil, false, fmt.Errorf("unable to read queue entry: %w", err)
+	}
+
+	return seq, data, true, nil
+}
+
+// Remove removes the request with the given sequence number from the queue.
+func (q *Queue) Remove(seq uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.remove(seq)
+}
+
+func (q *Queue) remove(seq uint64) error {
+	if err := os.Remove(q.path(seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to remove queue entry: %w", err)
+	}
+
+	q.seqs = slices.DeleteFunc(q.seqs, func(s uint64) bool { return s == seq })
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package remotewrite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drain removes and returns all entries of q in order.
+func drain(t *testing.T, q *Queue) []string {
+	t.Helper()
+
+	var out []string
+
+	for {
+		seq, data, ok, err := q.Peek()
+		require.NoError(t, err)
+
+		if !ok {
+			return out
+		}
+
+		out = append(out, string(data))
+		require.NoError(t, q.Remove(seq))
+	}
+}
+
+func appendAll(t *testing.T, q *Queue, entries ...string) {
+	t.Helper()
+
+	for _, e := range entries {
+		_, err := q.Append([]byte(e))
+		require.NoError(t, err)
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	q, err := OpenQueue(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	// More than ten entries, so a lexical sort of unpadded names would reorder them.
+	entries := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}
+	appendAll(t, q, entries...)
+
+	assert.Equal(t, len(entries), q.Len())
+	assert.Equal(t, entries, drain(t, q))
+	assert.Zero(t, q.Len())
+}
+
+func TestQueuePersistsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := OpenQueue(dir, 0)
+	require.NoError(t, err)
+
+	appendAll(t, q, "first", "second", "third")
+
+	seq, _, _, err := q.Peek()
+	require.NoError(t, err)
+	require.NoError(t, q.Remove(seq))
+
+	// Leftovers of an interrupted write and unrelated files are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000009.rw.tmp"), []byte("partial"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("unrelated"), 0o600))
+
+	q, err = OpenQueue(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, q.Len())
+
+	// New entries continue after the highest sequence number on disk.
+	appendAll(t, q, "fourth")
+	assert.Equal(t, []string{"second", "third", "fourth"}, drain(t, q))
+}
+
+func TestQueueEvictsOldest(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := OpenQueue(dir, 3)
+	require.NoError(t, err)
+
+	appendAll(t, q, "1", "2", "3")
+
+	dropped, err := q.Append([]byte("4"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 3, q.Len())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+queueFileSuffix))
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	// A restart with a smaller limit drops the excess on the next append.
+	q, err = OpenQueue(dir, 2)
+	require.NoError(t, err)
+
+	dropped, err = q.Append([]byte("5"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, dropped)
+	assert.Equal(t, []string{"4", "5"}, drain(t, q))
+}
+
+func TestQueueEmpty(t *testing.T) {
+	q, err := OpenQueue(filepath.Join(t.TempDir(), "nested", "queue"), 0)
+	require.NoError(t, err)
+
+	_, _, ok, err := q.Peek()
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,160 @@
+/*
+Package remotewrite sends metrics to a Prometheus remote write endpoint such as Mimir, Thanos or
+Prometheus itself. Requests are buffered on disk before they are sent, so readings collected while
+the endpoint is unreachable are replayed in order once it is back. The protocol is documented here:
+
+https://prometheus.io/docs/specs/remote_write_spec/
+*/
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/schubergphilis/rpi_exporter/pkg/version"
+)
+
+const (
+	contentType   = "application/x-protobuf"
+	protoVersion  = "0.1.0"
+	maxErrorBody  = 512
+	userAgentName = "rpi_exporter/"
+)
+
+// errUnrecoverable marks requests the endpoint rejected permanently. Retrying them is pointless.
+var errUnrecoverable = errors.New("request rejected")
+
+// Sender sends remote write requests from a queue to an endpoint.
+type Sender struct {
+	// Client is the HTTP client used for requests.
+	Client *http.Client
+	// Username and Password enable basic auth if Username is set.
+	Username string
+	Password string
+	// BearerToken is sent as Authorization header if set. It takes precedence over basic auth.
+	BearerToken string
+
+	url   string
+	queue *Queue
+}
+
+// New returns a sender that sends requests buffered in queue to the remote write endpoint at
+// endpointURL.
+func New(endpointURL string, queue *Queue) (*Sender, error) {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote write url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid remote write url %q: scheme must be http or https", endpointURL)
+	}
+
+	return &Sender{Client: http.DefaultClient, url: u.String(), queue: queue}, nil
+}
+
+// URL returns the URL of the endpoint.
+func (s *Sender) URL() string {
+	return s.url
+}
+
+// Enqueue compresses an uncompressed write request and appends it to the queue. It returns the
+// number of old requests dropped because the queue is full.
+func (s *Sender) Enqueue(req []byte) (int, error) {
+	return s.queue.Append(snappy.Encode(nil, req))
+}
+
+// Flush sends queued requests oldest first until the queue is empty. It stops at the first request
+// that fails with a recoverable error, so it can be retried in order by the next Flush. Requests
+// the endpoint rejects permanently, and entries that are missing or corrupt, are dropped. It returns
+// the number of requests sent.
+func (s *Sender) Flush(ctx context.Context) (int, error) {
+	sent := 0
+
+	for {
+		seq, data, ok, err := s.queue.Peek()
+		if err != nil {
+			// A missing entry is lost for good, but other read errors may be transient and leave the
+			// entry for the next Flush.
+			if errors.Is(err, os.ErrNotExist) {
+				return sent, errors.Join(err, s.queue.Remove(seq))
+			}
+
+			return sent, err
+		}
+
+		if !ok {
+			return sent, nil
+		}
+
+		// A corrupt entry would be rejected by the endpoint forever and block the queue.
+		if _, err := snappy.Decode(nil, data); err != nil {
+			return sent, errors.Join(fmt.Errorf("unable to decode queue entry %d: %w", seq, err), s.queue.Remove(seq))
+		}
+
+		err = s.send(ctx, data)
+		if err != nil && !errors.Is(err, errUnrecoverable) {
+			return sent, err
+		}
+
+		if rmErr := s.queue.Remove(seq); rmErr != nil {
+			return sent, rmErr
+		}
+
+		if err != nil {
+			return sent, err
+		}
+
+		sent++
+	}
+}
+
+func (s *Sender) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("User-Agent", userAgentName+version.Get().Version)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", protoVersion)
+
+	switch {
+	case s.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+s.BearerToken)
+	case s.Username != "":
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send metrics: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	err = fmt.Errorf("unexpected status %s from remote write endpoint: %s", resp.Status,
+		strings.TrimSpace(string(msg)))
+
+	// Per the spec, only 5xx and 429 responses may be retried.
+	if resp.StatusCode/100 != 5 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", errUnrecoverable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,228 @@
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endpoint is a remote write receiver stand-in that records decoded requests and answers with the
+// next status in statuses, or 204 once they are used up.
+type endpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	compressed, _ := io.ReadAll(r.Body)
+
+	body, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	e.mu.Lock()
+	e.requests = append(e.requests, r)
+	e.bodies = append(e.bodies, string(body))
+
+	status := http.StatusNoContent
+	if len(e.statuses) > 0 {
+		status, e.statuses = e.statuses[0], e.statuses[1:]
+	}
+	e.mu.Unlock()
+
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, http.StatusText(status))
+}
+
+func newTestSender(t *testing.T, e *endpoint) *Sender {
+	t.Helper()
+
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	q, err := OpenQueue(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	s, err := New(srv.URL+"/api/v1/push", q)
+	require.NoError(t, err)
+
+	return s
+}
+
+func enqueue(t *testing.T, s *Sender, requests ...string) {
+	t.Helper()
+
+	for _, r := range requests {
+		_, err := s.Enqueue([]byte(r))
+		require.NoError(t, err)
+	}
+}
+
+// queued drains the queue of s and returns the decompressed requests.
+func queued(t *testing.T, s *Sender) []string {
+	t.Helper()
+
+	var out []string
+
+	for _, compressed := range drain(t, s.queue) {
+		body, err := snappy.Decode(nil, []byte(compressed))
+		require.NoError(t, err)
+
+		out = append(out, string(body))
+	}
+
+	return out
+}
+
+func TestNew(t *testing.T) {
+	for _, u := range []string{"localhost:9090/api/v1/write", "ftp://localhost", "://"} {
+		_, err := New(u, nil)
+		require.Error(t, err, u)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	e := &endpoint{}
+	s := newTestSender(t, e)
+	s.BearerToken = "token"
+
+	enqueue(t, s, "first", "second")
+
+	sent, err := s.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Zero(t, s.queue.Len())
+	assert.Equal(t, []string{"first", "second"}, e.bodies)
+
+	r := e.requests[0]
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, contentType, r.Header.Get("Content-Type"))
+	assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+	assert.Equal(t, protoVersion, r.Header.Get("X-Prometheus-Remote-Write-Version"))
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+}
+
+func TestFlushStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		kept   []string
+	}{
+		// Requests rejected permanently are dropped, and the rest of the queue is sent next time.
+		{http.StatusBadRequest, []string{"second"}},
+		{http.StatusUnauthorized, []string{"second"}},
+		// Recoverable failures keep the request at the head of the queue.
+		{http.StatusTooManyRequests, []string{"first", "second"}},
+		{http.StatusInternalServerError, []string{"first", "second"}},
+		{http.StatusServiceUnavailable, []string{"first", "second"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			e := &endpoint{statuses: []int{tt.status}}
+			s := newTestSender(t, e)
+
+			enqueue(t, s, "first", "second")
+
+			sent, err := s.Flush(context.Background())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), http.StatusText(tt.status))
+			assert.Zero(t, sent)
+			assert.Equal(t, tt.kept, queued(t, s))
+		})
+	}
+}
+
+func TestFlushDropsMissingEntry(t *testing.T) {
+	e := &endpoint{}
+	s := newTestSender(t, e)
+
+	enqueue(t, s, "first", "second")
+	require.NoError(t, os.Remove(s.queue.path(s.queue.seqs[0])))
+
+	_, err := s.Flush(context.Background())
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, 1, s.queue.Len())
+
+	sent, err := s.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"second"}, e.bodies)
+}
+
+func TestFlushDropsCorruptEntry(t *testing.T) {
+	e := &endpoint{}
+	s := newTestSender(t, e)
+
+	enqueue(t, s, "first", "second")
+
+	// A length header promising more data than the entry holds.
+	require.NoError(t, os.WriteFile(s.queue.path(s.queue.seqs[0]), []byte{0x40, 0x00, 'x'}, queueFileMode))
+
+	_, err := s.Flush(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 1, s.queue.Len())
+
+	sent, err := s.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"second"}, e.bodies)
+}
+
+func TestFlushKeepsUnreadableEntry(t *testing.T) {
+	e := &endpoint{}
+	s := newTestSender(t, e)
+
+	enqueue(t, s, "first", "second")
+
+	// Reading a directory fails like a flaky read would, without the entry being gone.
+	path := s.queue.path(s.queue.seqs[0])
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.Mkdir(path, queueDirMode))
+
+	_, err = s.Flush(context.Background())
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, 2, s.queue.Len())
+	assert.Empty(t, e.bodies)
+
+	// Once the entry can be read again, it is sent first.
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.WriteFile(path, data, queueFileMode))
+
+	sent, err := s.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{"first", "second"}, e.bodies)
+}
+
+func TestFlushUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	q, err := OpenQueue(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	s, err := New(srv.URL, q)
+	require.NoError(t, err)
+
+	enqueue(t, s, "first")
+
+	_, err = s.Flush(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 1, q.Len())
+}
@@ -0,0 +1,59 @@
+package snapshot
+
+import (
+	"sync"
+	"time"
+)
+
+// CacheStats describes how a snapshot was served by a Cache.
+type CacheStats struct {
+	Hits uint64        `json:"hits"`
+	Age  time.Duration `json:"age_ns"`
+}
+
+// Cache shares one snapshot between all callers within a minimum collection interval, so that
+// frequent or concurrent scrapes do not each sweep the mailbox.
+type Cache struct {
+	minInterval time.Duration
+	collect     func() (*Snapshot, error)
+
+	mu   sync.Mutex
+	snap *Snapshot
+	hits uint64
+	// done is the time the last collection finished. Callers that asked before it finished waited
+	// on the lock for that collection, and share its result.
+	done time.Time
+}
+
+// NewCache returns a cache that calls collect at most once per minInterval. A zero interval still
+// shares a snapshot between concurrent callers.
+func NewCache(minInterval time.Duration, collect func() (*Snapshot, error)) *Cache {
+	return &Cache{minInterval: minInterval, collect: collect}
+}
+
+// Get returns the cached snapshot if it is younger than the minimum interval, or collects a new
+// one. Callers arriving while a collection is in progress wait for it and share its result. The
+// returned snapshot is a copy with Exporter.Cache set, and must not be modified.
+func (c *Cache) Get() (*Snapshot, error) {
+	requested := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.snap != nil && (c.done.After(requested) || requested.Sub(c.snap.Time) < c.minInterval) {
+		c.hits++
+	} else {
+		snap, err := c.collect()
+		if err != nil {
+			return nil, err
+		}
+
+		c.snap = snap
+		c.done = time.Now()
+	}
+
+	snap := *c.snap
+	snap.Exporter.Cache = CacheStats{Hits: c.hits, Age: time.Since(snap.Time)}
+
+	return &snap, nil
+}
@@ -0,0 +1,105 @@
+package snapshot
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testWaiters      = 8
+	testWaitersDelay = 50 * time.Millisecond
+)
+
+func TestCacheCoalescesConcurrentCallers(t *testing.T) {
+	var calls atomic.Int32
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	cache := NewCache(0, func() (*Snapshot, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+
+		return &Snapshot{Time: time.Now()}, nil
+	})
+
+	var wg sync.WaitGroup
+
+	get := func() {
+		defer wg.Done()
+
+		_, err := cache.Get()
+		assert.NoError(t, err)
+	}
+
+	wg.Add(1)
+
+	go get()
+
+	<-started
+
+	wg.Add(testWaiters)
+
+	for range testWaiters {
+		go get()
+	}
+
+	// Give the waiters time to ask for a snapshot before the collection in progress finishes.
+	time.Sleep(testWaitersDelay)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+
+	// A caller arriving after the collection finished gets a fresh snapshot with a zero interval.
+	snap, err := cache.Get()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, uint64(testWaiters), snap.Exporter.Cache.Hits)
+}
+
+func TestCacheMinInterval(t *testing.T) {
+	var calls int
+
+	cache := NewCache(time.Hour, func() (*Snapshot, error) {
+		calls++
+
+		return &Snapshot{Time: time.Now()}, nil
+	})
+
+	for range 3 {
+		_, err := cache.Get()
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestCacheErrorNotCached(t *testing.T) {
+	errCollect := errors.New("collect failed")
+	calls := 0
+
+	cache := NewCache(time.Hour, func() (*Snapshot, error) {
+		calls++
+		if calls == 1 {
+			return nil, errCollect
+		}
+
+		return &Snapshot{Time: time.Now()}, nil
+	})
+
+	_, err := cache.Get()
+	require.ErrorIs(t, err, errCollect)
+
+	_, err = cache.Get()
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,98 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sections of a snapshot that can be enabled or disabled individually.
+const (
+	SectionHardware    = "hardware"
+	SectionPower       = "power"
+	SectionClocks      = "clocks"
+	SectionTemperature = "temperature"
+	SectionVoltage     = "voltage"
+	SectionThrottle    = "throttle"
+	SectionSampler     = "sampler"
+	SectionExporter    = "exporter"
+)
+
+// Sections lists all sections in collection order.
+var Sections = []string{
+	SectionHardware,
+	SectionPower,
+	SectionClocks,
+	SectionTemperature,
+	SectionVoltage,
+	SectionThrottle,
+	SectionSampler,
+	SectionExporter,
+}
+
+// Filter selects the sections of a snapshot. A nil filter selects all sections.
+type Filter map[string]bool
+
+// NewFilter returns a filter selecting the given sections.
+func NewFilter(sections ...string) (Filter, error) {
+	f := make(Filter, len(sections))
+
+	for _, section := range sections {
+		if !isSection(section) {
+			return nil, fmt.Errorf("unknown section %q, expected one of: %s", section, strings.Join(Sections, ", "))
+		}
+
+		f[section] = true
+	}
+
+	return f, nil
+}
+
+func isSection(name string) bool {
+	for _, section := range Sections {
+		if section == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Enabled reports whether the filter selects section.
+func (f Filter) Enabled(section string) bool {
+	return f == nil || f[section]
+}
+
+// Intersect returns a filter selecting the sections selected by both f and other.
+func (f Filter) Intersect(other Filter) Filter {
+	if f == nil {
+		return other
+	}
+
+	if other == nil {
+		return f
+	}
+
+	out := make(Filter, len(f))
+
+	for section := range f {
+		if other[section] {
+			out[section] = true
+		}
+	}
+
+	return out
+}
+
+// Has reports whether the snapshot includes section.
+func (s *Snapshot) Has(section string) bool {
+	return s.Filter.Enabled(section)
+}
+
+// Filtered returns a copy of the snapshot that only includes the sections selected by both the
+// snapshot and f.
+func (s *Snapshot) Filtered(f Filter) *Snapshot {
+	snap := *s
+	snap.Filter = s.Filter.Intersect(f)
+
+	return &snap
+}
@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+	"github.com/schubergphilis/rpi_exporter/pkg/version"
+)
+
+// Field indexes of /proc/self/stat, counted from the state field that follows the command name.
+const (
+	procStatUtime     = 11
+	procStatStime     = 12
+	procStatStartTime = 19
+	procStatVsize     = 20
+	procStatRss       = 21
+)
+
+// procClockTicks is USER_HZ, which is 100 on all architectures supported by Raspberry Pi OS.
+const procClockTicks = 100
+
+// Exporter holds the self-instrumentation of the exporter process.
+type Exporter struct {
+	Build   version.Info     `json:"build"`
+	Mailbox mbox.IoctlStats  `json:"mailbox"`
+	Process Reading[Process] `json:"process"`
+	Runtime Runtime          `json:"runtime"`
+	Cache   CacheStats       `json:"cache"`
+}
+
+// Process holds resource usage of the exporter process as read from /proc/self.
+type Process struct {
+	CPUSeconds    float64   `json:"cpu_seconds"`
+	ResidentBytes uint64    `json:"resident_bytes"`
+	VirtualBytes  uint64    `json:"virtual_bytes"`
+	OpenFDs       int       `json:"open_fds"`
+	MaxFDs        uint64    `json:"max_fds"`
+	StartTime     time.Time `json:"start_time"`
+}
+
+// Runtime holds Go runtime statistics of the exporter process.
+type Runtime struct {
+	Goroutines     int    `json:"goroutines"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	SysBytes       uint64 `json:"sys_bytes"`
+}
+
+func (s *Snapshot) collectExporter() {
+	var mem runtime.MemStats
+
+	runtime.ReadMemStats(&mem)
+
+	s.Exporter = Exporter{
+		Build:   version.Get(),
+		Mailbox: mbox.Stats(),
+		Process: read("process stats", readProcess),
+		Runtime: Runtime{
+			Goroutines:     runtime.NumGoroutine(),
+			HeapAllocBytes: mem.HeapAlloc,
+			SysBytes:       mem.Sys,
+		},
+	}
+}
+
+func readProcess() (Process, error) {
+	var p Process
+
+	stat, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return p, fmt.Errorf("unable to read process stat: %w", err)
+	}
+
+	// The command name may contain spaces and parentheses, so fields are split after the last one.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return p, errors.New("unable to parse process stat")
+	}
+
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) <= procStatRss {
+		return p, errors.New("unable to parse process stat: too few fields")
+	}
+
+	var v [procStatRss + 1]uint64
+
+	for _, idx := range []int{procStatUtime, procStatStime, procStatStartTime, procStatVsize, procStatRss} {
+		if v[idx], err = strconv.ParseUint(fields[idx], 10, 64); err != nil {
+			return p, fmt.Errorf("unable to parse process stat field %d: %w", idx, err)
+		}
+	}
+
+	bootTime, err := readBootTime()
+	if err != nil {
+		return p, err
+	}
+
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return p, fmt.Errorf("unable to read process fds: %w", err)
+	}
+
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return p, fmt.Errorf("unable to get fd limit: %w", err)
+	}
+
+	startTicks := time.Duration(v[procStatStartTime]) * time.Second / procClockTicks
+
+	p = Process{
+		CPUSeconds:    float64(v[procStatUtime]+v[procStatStime]) / procClockTicks,
+		ResidentBytes: v[procStatRss] * uint64(os.Getpagesize()),
+		VirtualBytes:  v[procStatVsize],
+		OpenFDs:       len(fds),
+		MaxFDs:        limit.Cur,
+		StartTime:     bootTime.Add(startTicks),
+	}
+
+	return p, nil
+}
+
+// readBootTime returns the system boot time from /proc/stat.
+func readBootTime() (time.Time, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to open /proc/stat: %w", err)
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if btime, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			sec, err := strconv.ParseInt(strings.TrimSpace(btime), 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("unable to parse boot time: %w", err)
+			}
+
+			return time.Unix(sec, 0), nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, fmt.Errorf("unable to read /proc/stat: %w", err)
+	}
+
+	return time.Time{}, errors.New("boot time not found in /proc/stat")
+}
@@ -0,0 +1,238 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+)
+
+// TemperatureBuckets are the upper bounds in degrees celsius of the sampled temperature histogram.
+var TemperatureBuckets = []float64{30, 40, 50, 55, 60, 65, 70, 75, 80, 85, 90}
+
+// ClockBuckets are the upper bounds in Hertz of the sampled clock rate histograms.
+var ClockBuckets = []float64{
+	100e6, 200e6, 250e6, 300e6, 400e6, 500e6, 600e6, 750e6, 1000e6, 1200e6, 1500e6, 1800e6, 2000e6, 2400e6,
+}
+
+// SampledClocks are the clocks measured by the sampler.
+var SampledClocks = map[mbox.ClockID]string{
+	mbox.ClockIDARM:  "arm",
+	mbox.ClockIDCore: "core",
+}
+
+// Histogram is a cumulative histogram of sampled values.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	// Counts holds the cumulative count of observations per upper bound in Bounds.
+	Counts []uint64 `json:"counts"`
+	Count  uint64   `json:"count"`
+	Sum    float64  `json:"sum"`
+}
+
+func newHistogram(bounds []float64) Histogram {
+	return Histogram{Bounds: bounds, Counts: make([]uint64, len(bounds))}
+}
+
+func (h *Histogram) observe(v float64) {
+	h.Count++
+	h.Sum += v
+
+	for i, upper := range h.Bounds {
+		if v <= upper {
+			h.Counts[i]++
+		}
+	}
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]uint64{}, h.Counts...)
+
+	return h
+}
+
+// Series holds the histogram of a sampled value since start and its range since the last drain.
+type Series struct {
+	Histogram Histogram `json:"histogram"`
+	// Min and Max are NaN if nothing was sampled since the last drain.
+	Min float64
+	Max float64
+}
+
+// MarshalJSON encodes the series, omitting the minimum and maximum if nothing was sampled.
+func (s Series) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Histogram Histogram `json:"histogram"`
+		Min       *float64  `json:"min,omitempty"`
+		Max       *float64  `json:"max,omitempty"`
+	}{Histogram: s.Histogram}
+
+	if !math.IsNaN(s.Min) {
+		out.Min, out.Max = &s.Min, &s.Max
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal series: %w", err)
+	}
+
+	return b, nil
+}
+
+func newSeries(bounds []float64) *Series {
+	return &Series{Histogram: newHistogram(bounds), Min: math.NaN(), Max: math.NaN()}
+}
+
+func (s *Series) observe(v float64) {
+	s.Histogram.observe(v)
+
+	if math.IsNaN(s.Min) || v < s.Min {
+		s.Min = v
+	}
+
+	if math.IsNaN(s.Max) || v > s.Max {
+		s.Max = v
+	}
+}
+
+// Samples holds the readings of the background sampler.
+type Samples struct {
+	Since       time.Time         `json:"since"`
+	Interval    time.Duration     `json:"interval_ns"`
+	Count       uint64            `json:"count"`
+	Errors      uint64            `json:"errors"`
+	Temperature Series            `json:"temperature"`
+	Clocks      map[string]Series `json:"clocks"`
+	// Throttled holds all throttled flags seen since the last drain.
+	Throttled uint32 `json:"throttled"`
+}
+
+// Sampler reads temperature, measured clocks and throttled state at a high rate in the background,
+// so that transient spikes and dips between scrapes become visible.
+type Sampler struct {
+	interval time.Duration
+
+	mu          sync.Mutex
+	since       time.Time
+	count       uint64
+	errors      uint64
+	temperature *Series
+	clocks      map[string]*Series
+	throttled   uint32
+}
+
+// NewSampler returns a sampler that reads the mailbox every interval once started.
+func NewSampler(interval time.Duration) *Sampler {
+	s := &Sampler{
+		interval:    interval,
+		since:       time.Now(),
+		temperature: newSeries(TemperatureBuckets),
+		clocks:      make(map[string]*Series, len(SampledClocks)),
+	}
+
+	for _, label := range SampledClocks {
+		s.clocks[label] = newSeries(ClockBuckets)
+	}
+
+	return s
+}
+
+// Run opens the mailbox and samples until ctx is done.
+func (s *Sampler) Run(ctx context.Context) error {
+	mboxOpen, err := mbox.Open()
+	if err != nil {
+		return fmt.Errorf("unable to open mbox: %w", err)
+	}
+
+	defer mboxOpen.Close()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Sample(mboxOpen)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample takes a single sample from src.
+func (s *Sampler) Sample(src Source) {
+	temp, tempErr := src.GetTemperature()
+	throttled, throttledErr := src.GetThrottled()
+
+	clocks := make(map[string]int, len(SampledClocks))
+	clockErr := false
+
+	for id, label := range SampledClocks {
+		rate, err := src.GetClockRateMeasured(id)
+		if err != nil {
+			clockErr = true
+
+			continue
+		}
+
+		clocks[label] = rate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.count++
+
+	if tempErr != nil || throttledErr != nil || clockErr {
+		s.errors++
+	}
+
+	if tempErr == nil {
+		s.temperature.observe(float64(temp))
+	}
+
+	if throttledErr == nil {
+		s.throttled |= throttled
+	}
+
+	for label, rate := range clocks {
+		s.clocks[label].observe(float64(rate))
+	}
+}
+
+// Drain returns the samples and resets the minimum, maximum and throttled flags collected since
+// the previous drain. Histograms and counts are cumulative.
+func (s *Sampler) Drain() *Samples {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples := &Samples{
+		Since:       s.since,
+		Interval:    s.interval,
+		Count:       s.count,
+		Errors:      s.errors,
+		Temperature: drainSeries(s.temperature),
+		Clocks:      make(map[string]Series, len(s.clocks)),
+		Throttled:   s.throttled,
+	}
+
+	for label, series := range s.clocks {
+		samples.Clocks[label] = drainSeries(series)
+	}
+
+	s.throttled = 0
+
+	return samples
+}
+
+func drainSeries(s *Series) Series {
+	out := Series{Histogram: s.Histogram.clone(), Min: s.Min, Max: s.Max}
+	s.Min, s.Max = math.NaN(), math.NaN()
+
+	return out
+}
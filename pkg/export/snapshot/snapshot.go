@@ -0,0 +1,408 @@
+/*
+Package snapshot provides a typed model of the hardware readings found in the Mailbox Property
+Interface of a Raspberry Pi, so they can be consumed as data rather than exposition text.
+
+A Snapshot is collected once from a Source and handed to renderers, which only format it.
+*/
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+)
+
+// VoltageLabels names the voltage rails included in a snapshot.
+var VoltageLabels = map[mbox.VoltageID]string{
+	mbox.VoltageIDCore:   "core",
+	mbox.VoltageIDSDRAMC: "sdram_c",
+	mbox.VoltageIDSDRAMI: "sdram_i",
+	mbox.VoltageIDSDRAMP: "sdram_p",
+}
+
+// PowerLabels names the power devices included in a snapshot.
+var PowerLabels = map[mbox.PowerDeviceID]string{
+	mbox.PowerDeviceIDSDCard: "sd_card",
+	mbox.PowerDeviceIDUART0:  "uart0",
+	mbox.PowerDeviceIDUART1:  "uart1",
+	mbox.PowerDeviceIDUSBHCD: "usb_hcd",
+	mbox.PowerDeviceIDI2C0:   "i2c0",
+	mbox.PowerDeviceIDI2C1:   "i2c1",
+	mbox.PowerDeviceIDI2C2:   "i2c2",
+	mbox.PowerDeviceIDSPI:    "spi",
+	mbox.PowerDeviceIDCCP2TX: "ccp2tx",
+}
+
+// ClockLabels names the clocks included in a snapshot.
+var ClockLabels = map[mbox.ClockID]string{
+	mbox.ClockIDEMMC:     "emmc",
+	mbox.ClockIDUART:     "uart",
+	mbox.ClockIDARM:      "arm",
+	mbox.ClockIDCore:     "core",
+	mbox.ClockIDV3D:      "v3d",
+	mbox.ClockIDH264:     "h264",
+	mbox.ClockIDISP:      "isp",
+	mbox.ClockIDSDRAM:    "sdram",
+	mbox.ClockIDPixel:    "pixel",
+	mbox.ClockIDPWM:      "pwm",
+	mbox.ClockIDHEVC:     "hevc",
+	mbox.ClockIDEMMC2:    "emmc2",
+	mbox.ClockIDM2MC:     "m2mc",
+	mbox.ClockIDPixelBVB: "pixel_bvb",
+}
+
+// Source is the subset of the mailbox used to collect a snapshot. It is implemented by
+// *mbox.Mailbox.
+type Source interface {
+	GetFirmwareRevision() (uint32, error)
+	GetFirmwareVariant() (mbox.FirmwareVariant, error)
+	GetFirmwareHash() (string, error)
+	GetBoardModel() (uint32, error)
+	GetBoardRevision() (uint32, error)
+	GetBoardSerial() (uint64, error)
+	GetPowerState(id mbox.PowerDeviceID) (mbox.PowerState, error)
+	GetClockRate(id mbox.ClockID) (int, error)
+	GetClockRateMeasured(id mbox.ClockID) (int, error)
+	GetTemperature() (float32, error)
+	GetMaxTemperature() (float32, error)
+	GetVoltage(id mbox.VoltageID) (float32, error)
+	GetMinVoltage(id mbox.VoltageID) (float32, error)
+	GetMaxVoltage(id mbox.VoltageID) (float32, error)
+	GetTurbo() (bool, error)
+	GetThrottled() (uint32, error)
+}
+
+// Reading is a single value read from the mailbox, with the time it was read and the error, if
+// any, that prevented reading it.
+type Reading[T any] struct {
+	Value T
+	Time  time.Time
+	Err   error
+}
+
+// OK reports whether the value was read successfully.
+func (r Reading[T]) OK() bool {
+	return r.Err == nil
+}
+
+// MarshalJSON encodes the reading as an object with value, time and error fields. The value is
+// omitted if the reading failed.
+func (r Reading[T]) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Value *T        `json:"value,omitempty"`
+		Time  time.Time `json:"time"`
+		Error string    `json:"error,omitempty"`
+	}{Time: r.Time}
+
+	if r.Err != nil {
+		out.Error = r.Err.Error()
+	} else {
+		out.Value = &r.Value
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal reading: %w", err)
+	}
+
+	return b, nil
+}
+
+// Float64 converts a float32 reading to float64 without exposing float32 rounding noise, so that
+// 0.8 is not exported as 0.800000011920929.
+func Float64(v float32) float64 {
+	f, err := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	if err != nil {
+		return float64(v)
+	}
+
+	return f
+}
+
+// read calls get and records the result as a reading.
+func read[T any](name string, get func() (T, error)) Reading[T] {
+	v, err := get()
+	if err != nil {
+		err = fmt.Errorf("unable to get %s: %w", name, err)
+	}
+
+	return Reading[T]{Value: v, Time: time.Now(), Err: err}
+}
+
+// Snapshot holds all hardware readings collected in a single sweep of the mailbox.
+type Snapshot struct {
+	// Filter selects the sections included in the snapshot.
+	Filter      Filter                     `json:"-"`
+	Time        time.Time                  `json:"time"`
+	Duration    time.Duration              `json:"duration_ns"`
+	Board       Board                      `json:"board"`
+	Clocks      map[string]Clock           `json:"clocks"`
+	Voltages    map[string]Voltage         `json:"voltages"`
+	Temperature Temperature                `json:"temperature"`
+	Power       map[string]Reading[uint32] `json:"power"`
+	Throttle    Throttle                   `json:"throttle"`
+	Exporter    Exporter                   `json:"exporter"`
+	// Samples is only set if a Sampler is running.
+	Samples *Samples `json:"samples,omitempty"`
+	// ThrottleEvents is only set if a ThrottleMonitor is running.
+	ThrottleEvents *ThrottleEvents `json:"throttle_events,omitempty"`
+}
+
+// Board describes the system board and VideoCore firmware.
+type Board struct {
+	FirmwareRevision Reading[uint32] `json:"firmware_revision"`
+	FirmwareVariant  Reading[string] `json:"firmware_variant"`
+	FirmwareHash     Reading[string] `json:"firmware_hash"`
+	Model            Reading[uint32] `json:"model"`
+	Revision         Reading[uint32] `json:"revision"`
+	// Serial is formatted as in /proc/cpuinfo.
+	Serial Reading[string] `json:"serial"`
+}
+
+// Clock holds the configured and measured rate of a clock in Hertz.
+type Clock struct {
+	RateHz     Reading[int] `json:"rate_hz"`
+	MeasuredHz Reading[int] `json:"measured_hz"`
+}
+
+// Voltage holds the current, minimum and maximum supported voltage of a rail.
+type Voltage struct {
+	Volts    Reading[float32] `json:"volts"`
+	MinVolts Reading[float32] `json:"min_volts"`
+	MaxVolts Reading[float32] `json:"max_volts"`
+}
+
+// Temperature holds the current and maximum safe temperature of the SoC in degrees celsius.
+type Temperature struct {
+	Celsius    Reading[float32] `json:"celsius"`
+	MaxCelsius Reading[float32] `json:"max_celsius"`
+}
+
+// Throttle holds the throttled state of the SoC and whether turbo mode is enabled. The turbo state
+// is collected with the clocks section.
+type Throttle struct {
+	State Reading[ThrottleState] `json:"state"`
+	Turbo Reading[bool]          `json:"turbo"`
+}
+
+// ThrottleState decodes the bitmask returned by mbox.Mailbox.GetThrottled.
+type ThrottleState struct {
+	Flags                 uint32 `json:"flags"`
+	UnderVoltage          bool   `json:"under_voltage"`
+	FreqCapped            bool   `json:"freq_capped"`
+	Throttled             bool   `json:"throttled"`
+	SoftTempLimit         bool   `json:"soft_temp_limit"`
+	UnderVoltageOccurred  bool   `json:"under_voltage_occurred"`
+	FreqCappedOccurred    bool   `json:"freq_capped_occurred"`
+	ThrottledOccurred     bool   `json:"throttled_occurred"`
+	SoftTempLimitOccurred bool   `json:"soft_temp_limit_occurred"`
+}
+
+// NewThrottleState decodes a throttled bitmask.
+func NewThrottleState(flags uint32) ThrottleState {
+	return ThrottleState{
+		Flags:                 flags,
+		UnderVoltage:          flags&mbox.ThrottledUnderVoltage != 0,
+		FreqCapped:            flags&mbox.ThrottledFreqCapped != 0,
+		Throttled:             flags&mbox.ThrottledThrottled != 0,
+		SoftTempLimit:         flags&mbox.ThrottledSoftTempLimit != 0,
+		UnderVoltageOccurred:  flags&mbox.ThrottledUnderVoltageOccurred != 0,
+		FreqCappedOccurred:    flags&mbox.ThrottledFreqCappedOccurred != 0,
+		ThrottledOccurred:     flags&mbox.ThrottledThrottledOccurred != 0,
+		SoftTempLimitOccurred: flags&mbox.ThrottledSoftTempLimitOccurred != 0,
+	}
+}
+
+// Collect opens the mailbox and collects a snapshot of all sections. An error is only returned if
+// the mailbox cannot be opened; errors reading individual values are recorded in the snapshot.
+func Collect() (*Snapshot, error) {
+	return CollectFiltered(nil)
+}
+
+// CollectFiltered opens the mailbox and collects a snapshot of the sections selected by filter.
+func CollectFiltered(filter Filter) (*Snapshot, error) {
+	mboxOpen, err := mbox.Open()
+	if err != nil {
+		return nil, fmt.Errorf("unable to open mbox: %w", err)
+	}
+
+	defer mboxOpen.Close()
+
+	return CollectFrom(mboxOpen, filter), nil
+}
+
+// CollectFrom collects a snapshot of the sections selected by filter from src.
+func CollectFrom(src Source, filter Filter) *Snapshot {
+	snap := &Snapshot{Time: time.Now(), Filter: filter}
+
+	for _, section := range []struct {
+		name    string
+		collect func(Source)
+	}{
+		{SectionHardware, snap.collectBoard},
+		{SectionClocks, snap.collectClocks},
+		{SectionVoltage, snap.collectVoltages},
+		{SectionTemperature, snap.collectTemperature},
+		{SectionPower, snap.collectPower},
+		{SectionThrottle, snap.collectThrottle},
+	} {
+		if filter.Enabled(section.name) {
+			section.collect(src)
+		}
+	}
+
+	snap.Duration = time.Since(snap.Time)
+
+	if filter.Enabled(SectionExporter) {
+		snap.collectExporter()
+	}
+
+	return snap
+}
+
+// Err returns all errors recorded while collecting the snapshot, or nil.
+func (s *Snapshot) Err() error {
+	var errs []error
+
+	for _, r := range append(s.mailboxReadings(), s.Exporter.Process) {
+		if _, err := r.outcome(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Readings returns the number of mailbox readings collected successfully and the number that
+// failed. Readings of sections that were not collected are not counted.
+func (s *Snapshot) Readings() (int, int) {
+	var ok, failed int
+
+	for _, r := range s.mailboxReadings() {
+		switch collected, err := r.outcome(); {
+		case !collected:
+		case err != nil:
+			failed++
+		default:
+			ok++
+		}
+	}
+
+	return ok, failed
+}
+
+// outcome is implemented by readings of any type.
+type outcome interface {
+	outcome() (bool, error)
+}
+
+// outcome reports whether the reading was collected, and the error that prevented reading it.
+func (r Reading[T]) outcome() (bool, error) {
+	return !r.Time.IsZero(), r.Err
+}
+
+// mailboxReadings returns the readings taken from the mailbox, in a stable order.
+func (s *Snapshot) mailboxReadings() []outcome {
+	readings := []outcome{
+		s.Board.FirmwareRevision,
+		s.Board.FirmwareVariant,
+		s.Board.FirmwareHash,
+		s.Board.Model,
+		s.Board.Revision,
+		s.Board.Serial,
+	}
+
+	for _, label := range slices.Sorted(maps.Keys(s.Clocks)) {
+		readings = append(readings, s.Clocks[label].RateHz, s.Clocks[label].MeasuredHz)
+	}
+
+	for _, label := range slices.Sorted(maps.Keys(s.Voltages)) {
+		readings = append(readings, s.Voltages[label].Volts, s.Voltages[label].MinVolts, s.Voltages[label].MaxVolts)
+	}
+
+	readings = append(readings, s.Temperature.Celsius, s.Temperature.MaxCelsius)
+
+	for _, label := range slices.Sorted(maps.Keys(s.Power)) {
+		readings = append(readings, s.Power[label])
+	}
+
+	return append(readings, s.Throttle.State, s.Throttle.Turbo)
+}
+
+func (s *Snapshot) collectBoard(src Source) {
+	s.Board = Board{
+		FirmwareRevision: read("firmware revision", src.GetFirmwareRevision),
+		FirmwareVariant: read("firmware variant", func() (string, error) {
+			variant, err := src.GetFirmwareVariant()
+
+			return variant.String(), err
+		}),
+		FirmwareHash: read("firmware hash", src.GetFirmwareHash),
+		Model:        read("board model", src.GetBoardModel),
+		Revision:     read("board revision", src.GetBoardRevision),
+		Serial: read("board serial", func() (string, error) {
+			serial, err := src.GetBoardSerial()
+
+			return fmt.Sprintf("%016x", serial), err
+		}),
+	}
+}
+
+func (s *Snapshot) collectClocks(src Source) {
+	s.Clocks = make(map[string]Clock, len(ClockLabels))
+	s.Throttle.Turbo = read("turbo", src.GetTurbo)
+
+	for id, label := range ClockLabels {
+		s.Clocks[label] = Clock{
+			RateHz: read("clock rate", func() (int, error) { return src.GetClockRate(id) }),
+			MeasuredHz: read("measured clock rate", func() (int, error) {
+				return src.GetClockRateMeasured(id)
+			}),
+		}
+	}
+}
+
+func (s *Snapshot) collectVoltages(src Source) {
+	s.Voltages = make(map[string]Voltage, len(VoltageLabels))
+
+	for id, label := range VoltageLabels {
+		s.Voltages[label] = Voltage{
+			Volts:    read("voltage", func() (float32, error) { return src.GetVoltage(id) }),
+			MinVolts: read("minimum voltage", func() (float32, error) { return src.GetMinVoltage(id) }),
+			MaxVolts: read("max voltage", func() (float32, error) { return src.GetMaxVoltage(id) }),
+		}
+	}
+}
+
+func (s *Snapshot) collectTemperature(src Source) {
+	s.Temperature = Temperature{
+		Celsius:    read("temperature", src.GetTemperature),
+		MaxCelsius: read("maximum temperature", src.GetMaxTemperature),
+	}
+}
+
+func (s *Snapshot) collectPower(src Source) {
+	s.Power = make(map[string]Reading[uint32], len(PowerLabels))
+
+	for id, label := range PowerLabels {
+		s.Power[label] = read("power state", func() (uint32, error) {
+			state, err := src.GetPowerState(id)
+
+			return uint32(state), err
+		})
+	}
+}
+
+func (s *Snapshot) collectThrottle(src Source) {
+	s.Throttle.State = read("throttled state", func() (ThrottleState, error) {
+		flags, err := src.GetThrottled()
+
+		return NewThrottleState(flags), err
+	})
+}
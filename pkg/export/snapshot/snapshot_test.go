@@ -0,0 +1,38 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestRead = errors.New("read failed")
+
+func TestReadingsCountsCollectedSections(t *testing.T) {
+	src := mocks.NewSource(t)
+	src.EXPECT().GetTemperature().Return(50, nil)
+	src.EXPECT().GetMaxTemperature().Return(0, errTestRead)
+	src.EXPECT().GetThrottled().Return(0, errTestRead)
+
+	snap := CollectFrom(src, Filter{SectionTemperature: true, SectionThrottle: true})
+
+	ok, failed := snap.Readings()
+	assert.Equal(t, 1, ok)
+	assert.Equal(t, 2, failed)
+
+	err := snap.Err()
+	require.ErrorIs(t, err, errTestRead)
+	assert.Contains(t, err.Error(), "maximum temperature")
+	assert.Contains(t, err.Error(), "throttled state")
+}
+
+func TestReadingsEmptyFilter(t *testing.T) {
+	snap := CollectFrom(mocks.NewSource(t), Filter{SectionExporter: true})
+
+	ok, failed := snap.Readings()
+	assert.Zero(t, ok)
+	assert.Zero(t, failed)
+}
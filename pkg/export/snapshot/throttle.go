@@ -0,0 +1,151 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+	log "github.com/sirupsen/logrus"
+)
+
+// ThrottleCondition is a condition reported by the throttled flags, with its current and sticky bit.
+type ThrottleCondition struct {
+	Name     string
+	Active   uint32
+	Occurred uint32
+}
+
+// ThrottleConditions lists the conditions tracked by the ThrottleMonitor.
+var ThrottleConditions = []ThrottleCondition{
+	{"under_voltage", mbox.ThrottledUnderVoltage, mbox.ThrottledUnderVoltageOccurred},
+	{"freq_capped", mbox.ThrottledFreqCapped, mbox.ThrottledFreqCappedOccurred},
+	{"throttled", mbox.ThrottledThrottled, mbox.ThrottledThrottledOccurred},
+	{"soft_temp_limit", mbox.ThrottledSoftTempLimit, mbox.ThrottledSoftTempLimitOccurred},
+}
+
+// ThrottleEvents holds monotonic counters derived from the throttled flags.
+type ThrottleEvents struct {
+	Since time.Time `json:"since"`
+	// Events counts the occurrences of each condition by name.
+	Events map[string]uint64 `json:"events"`
+	// Seconds accumulates the time each condition was active by name.
+	Seconds map[string]float64 `json:"seconds"`
+	// Errors counts the polls that failed to read the throttled flags.
+	Errors uint64 `json:"errors"`
+}
+
+// ThrottledSource reads and clears the throttled flags. It is implemented by *mbox.Mailbox.
+type ThrottledSource interface {
+	GetThrottledAndClear(mask uint32) (uint32, error)
+}
+
+// ThrottleMonitor polls the throttled flags and counts events between polls. The sticky flags are
+// cleared on every poll, so that a condition that came and went between two polls is still counted.
+type ThrottleMonitor struct {
+	interval time.Duration
+
+	mu       sync.Mutex
+	since    time.Time
+	polled   bool
+	lastPoll time.Time
+	active   uint32
+	events   map[string]uint64
+	seconds  map[string]float64
+	errors   uint64
+}
+
+// NewThrottleMonitor returns a monitor that polls the mailbox every interval once started.
+func NewThrottleMonitor(interval time.Duration) *ThrottleMonitor {
+	return &ThrottleMonitor{
+		interval: interval,
+		since:    time.Now(),
+		events:   make(map[string]uint64, len(ThrottleConditions)),
+		seconds:  make(map[string]float64, len(ThrottleConditions)),
+	}
+}
+
+// Run opens the mailbox and polls until ctx is done. Failed polls are logged and counted, and
+// polling continues.
+func (t *ThrottleMonitor) Run(ctx context.Context) error {
+	mboxOpen, err := mbox.Open()
+	if err != nil {
+		return fmt.Errorf("unable to open mbox: %w", err)
+	}
+
+	defer mboxOpen.Close()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.Poll(mboxOpen); err != nil {
+			log.WithError(err).Warn("unable to poll throttled state")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reads and clears the throttled flags and updates the counters. A condition counts as a new
+// event if it occurred since the previous poll while it was not active at that poll. The first poll
+// only clears the flags, as they cover the time since boot. A failed poll is counted and leaves the
+// other counters unchanged.
+func (t *ThrottleMonitor) Poll(src ThrottledSource) error {
+	flags, err := src.GetThrottledAndClear(mbox.ThrottledClearAll)
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		t.errors++
+
+		return fmt.Errorf("unable to get throttled state: %w", err)
+	}
+
+	if t.polled {
+		elapsed := now.Sub(t.lastPoll).Seconds()
+
+		for _, c := range ThrottleConditions {
+			if flags&c.Occurred != 0 && t.active&c.Active == 0 {
+				t.events[c.Name]++
+			}
+
+			if flags&c.Active != 0 {
+				t.seconds[c.Name] += elapsed
+			}
+		}
+	}
+
+	t.polled = true
+	t.lastPoll = now
+	t.active = flags
+
+	return nil
+}
+
+// Events returns a copy of the counters.
+func (t *ThrottleMonitor) Events() *ThrottleEvents {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e := &ThrottleEvents{
+		Since:   t.since,
+		Events:  make(map[string]uint64, len(ThrottleConditions)),
+		Seconds: make(map[string]float64, len(ThrottleConditions)),
+		Errors:  t.errors,
+	}
+
+	for _, c := range ThrottleConditions {
+		e.Events[c.Name] = t.events[c.Name]
+		e.Seconds[c.Name] = t.seconds[c.Name]
+	}
+
+	return e
+}
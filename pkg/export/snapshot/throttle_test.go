@@ -0,0 +1,117 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot/mocks"
+	"github.com/schubergphilis/rpi_exporter/pkg/mbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestThrottled = errors.New("mailbox read failed")
+
+// testPollGap separates the polls of a sequence, so that active conditions accumulate time.
+const testPollGap = 20 * time.Millisecond
+
+type throttledResult struct {
+	flags uint32
+	err   error
+}
+
+// throttledSource returns the queued results in order.
+type throttledSource []throttledResult
+
+func (s *throttledSource) GetThrottledAndClear(uint32) (uint32, error) {
+	r := (*s)[0]
+	*s = (*s)[1:]
+
+	return r.flags, r.err
+}
+
+func TestThrottleMonitorPollErrors(t *testing.T) {
+	src := &throttledSource{
+		{0, nil},
+		{0, errTestThrottled},
+		{mbox.ThrottledUnderVoltage | mbox.ThrottledUnderVoltageOccurred, nil},
+		{0, errTestThrottled},
+		{mbox.ThrottledUnderVoltageOccurred, nil},
+	}
+
+	monitor := NewThrottleMonitor(0)
+
+	for range len(*src) {
+		_ = monitor.Poll(src)
+	}
+
+	events := monitor.Events()
+	assert.Equal(t, uint64(2), events.Errors)
+	// The condition stayed active across the failed poll and counts as a single event.
+	assert.Equal(t, uint64(1), events.Events["under_voltage"])
+	assert.Zero(t, events.Events["throttled"])
+}
+
+func TestThrottleMonitorPollReturnsError(t *testing.T) {
+	monitor := NewThrottleMonitor(0)
+
+	require.ErrorIs(t, monitor.Poll(&throttledSource{{0, errTestThrottled}}), errTestThrottled)
+	assert.Equal(t, uint64(1), monitor.Events().Errors)
+}
+
+func TestThrottleMonitorPollSequence(t *testing.T) {
+	const (
+		underVoltage = mbox.ThrottledUnderVoltage | mbox.ThrottledUnderVoltageOccurred
+		// freqCapped came and went between two polls, leaving only the sticky bit.
+		freqCapped = mbox.ThrottledFreqCappedOccurred
+	)
+
+	steps := []struct {
+		name  string
+		flags uint32
+		// events is the under-voltage event count after the poll.
+		events uint64
+		// accrues reports whether the under-voltage time grows with the poll.
+		accrues bool
+	}{
+		// The first poll only clears the flags accumulated since boot.
+		{"baseline", mbox.ThrottledUnderVoltageOccurred, 0, false},
+		{"set", underVoltage, 1, true},
+		{"still set", underVoltage, 1, true},
+		// The sticky bit is still set at the poll after the condition cleared, as it was active
+		// during part of the interval.
+		{"cleared", mbox.ThrottledUnderVoltageOccurred | freqCapped, 1, false},
+		{"still cleared", 0, 1, false},
+		{"set again", underVoltage, 2, true},
+	}
+
+	src := mocks.NewThrottledSource(t)
+	monitor := NewThrottleMonitor(0)
+
+	var seconds float64
+
+	for _, step := range steps {
+		src.EXPECT().GetThrottledAndClear(uint32(mbox.ThrottledClearAll)).Return(step.flags, nil).Once()
+
+		time.Sleep(testPollGap)
+		require.NoError(t, monitor.Poll(src), step.name)
+
+		events := monitor.Events()
+		assert.Equal(t, step.events, events.Events["under_voltage"], step.name)
+
+		if step.accrues {
+			assert.GreaterOrEqual(t, events.Seconds["under_voltage"]-seconds, testPollGap.Seconds(), step.name)
+		} else {
+			assert.InDelta(t, seconds, events.Seconds["under_voltage"], 0, step.name)
+		}
+
+		seconds = events.Seconds["under_voltage"]
+	}
+
+	events := monitor.Events()
+	assert.Equal(t, uint64(1), events.Events["freq_capped"])
+	assert.Zero(t, events.Seconds["freq_capped"])
+	assert.Zero(t, events.Events["throttled"])
+	assert.Zero(t, events.Errors)
+}
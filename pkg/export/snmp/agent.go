@@ -0,0 +1,383 @@
+/*
+Package snmp implements a read-only SNMPv2c agent serving the hardware snapshot of a Raspberry Pi
+under RPI-EXPORTER-MIB, along with the system group of SNMPv2-MIB. The MIB is generated from the
+same registry of objects the agent serves, see WriteMIB.
+*/
+package snmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/schubergphilis/rpi_exporter/pkg/version"
+	log "github.com/sirupsen/logrus"
+)
+
+// snmpVersion2c is the version field of SNMPv2c messages.
+const snmpVersion2c = 1
+
+// Error status values of responses.
+const (
+	errorNone        = 0
+	errorTooBig      = 1
+	errorGeneral     = 5
+	errorNotWritable = 17
+)
+
+const (
+	// DefaultMaxMessageSize is the largest response sent, small enough to avoid fragmentation.
+	DefaultMaxMessageSize = 1472
+	maxPacketSize         = 65535
+	centisecond           = 10 * time.Millisecond
+)
+
+// The system group of SNMPv2-MIB.
+var (
+	oidSysDescr    = OID{1, 3, 6, 1, 2, 1, 1, 1, 0}
+	oidSysObjectID = OID{1, 3, 6, 1, 2, 1, 1, 2, 0}
+	oidSysUpTime   = OID{1, 3, 6, 1, 2, 1, 1, 3, 0}
+	oidSysName     = OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+)
+
+// Agent answers SNMPv2c requests for the hardware snapshot.
+type Agent struct {
+	// Community is the read-only community. Requests with another community are dropped.
+	Community string
+	// Root is the root of RPI-EXPORTER-MIB.
+	Root OID
+	// MaxMessageSize limits the size of responses.
+	MaxMessageSize int
+
+	collect func() (*snapshot.Snapshot, error)
+	start   time.Time
+	sysName string
+}
+
+// NewAgent returns an agent serving snapshots returned by collect, which is called for every
+// request and should therefore be cached, for example by snapshot.Cache.
+func NewAgent(community string, collect func() (*snapshot.Snapshot, error)) *Agent {
+	hostname, _ := os.Hostname()
+
+	return &Agent{
+		Community:      community,
+		Root:           DefaultRoot,
+		MaxMessageSize: DefaultMaxMessageSize,
+		collect:        collect,
+		start:          time.Now(),
+		sysName:        hostname,
+	}
+}
+
+// Serve answers requests received on conn until ctx is done.
+func (a *Agent) Serve(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, maxPacketSize)
+
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("unable to read request: %w", err)
+		}
+
+		resp, err := a.handle(buf[:n])
+		if err != nil {
+			log.WithError(err).WithField("addr", addr).Debug("dropped snmp request")
+
+			continue
+		}
+
+		if _, err := conn.WriteTo(resp, addr); err != nil {
+			log.WithError(err).WithField("addr", addr).Warn("unable to send snmp response")
+		}
+	}
+}
+
+// request is a decoded SNMPv2c request PDU.
+type request struct {
+	tag       byte
+	id        int64
+	community []byte
+	// nonRepeaters and maxRepetitions are only set for GetBulkRequest.
+	nonRepeaters   int64
+	maxRepetitions int64
+	oids           []OID
+}
+
+func decodeRequest(msg []byte) (*request, error) {
+	d := decoder(msg)
+
+	content, err := d.expect(tagSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	d = decoder(content)
+
+	version, err := d.integer()
+	if err != nil {
+		return nil, err
+	}
+
+	if version != snmpVersion2c {
+		return nil, fmt.Errorf("unsupported snmp version %d", version)
+	}
+
+	req := &request{}
+
+	if req.community, err = d.expect(tagOctetString); err != nil {
+		return nil, err
+	}
+
+	tag, pdu, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+
+	req.tag = tag
+	d = decoder(pdu)
+
+	if req.id, err = d.integer(); err != nil {
+		return nil, err
+	}
+
+	if req.nonRepeaters, err = d.integer(); err != nil {
+		return nil, err
+	}
+
+	if req.maxRepetitions, err = d.integer(); err != nil {
+		return nil, err
+	}
+
+	list, err := d.expect(tagSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	for d = decoder(list); len(d) > 0; {
+		vb, err := d.expect(tagSequence)
+		if err != nil {
+			return nil, err
+		}
+
+		vbd := decoder(vb)
+
+		oid, err := vbd.oid()
+		if err != nil {
+			return nil, err
+		}
+
+		req.oids = append(req.oids, oid)
+	}
+
+	return req, nil
+}
+
+func (a *Agent) handle(msg []byte) ([]byte, error) {
+	req, err := decodeRequest(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(req.community) != a.Community {
+		return nil, errors.New("unknown community")
+	}
+
+	switch req.tag {
+	case tagGetRequest, tagGetNextRequest, tagGetBulkRequest:
+	case tagSetRequest:
+		return a.response(req, errorNotWritable, 1, responseBindings(req.oids, nil)), nil
+	default:
+		return nil, fmt.Errorf("unsupported pdu type 0x%02x", req.tag)
+	}
+
+	snap, err := a.collect()
+	if err != nil {
+		log.WithError(err).Error("unable to collect snapshot")
+
+		return a.response(req, errorGeneral, 0, responseBindings(req.oids, nil)), nil
+	}
+
+	view := a.view(snap)
+
+	var out []binding
+
+	switch req.tag {
+	case tagGetRequest:
+		for _, oid := range req.oids {
+			out = append(out, view.get(oid))
+		}
+	case tagGetNextRequest:
+		for _, oid := range req.oids {
+			out = append(out, view.next(oid))
+		}
+	case tagGetBulkRequest:
+		return a.bulk(req, view), nil
+	}
+
+	resp := a.response(req, errorNone, 0, out)
+	if len(resp) > a.MaxMessageSize {
+		return a.response(req, errorTooBig, 0, nil), nil
+	}
+
+	return resp, nil
+}
+
+// bulk answers a GetBulkRequest with as many repetitions as fit in a response.
+func (a *Agent) bulk(req *request, v view) []byte {
+	nonRepeaters := int(min(max(req.nonRepeaters, 0), int64(len(req.oids))))
+	maxRepetitions := int(max(req.maxRepetitions, 0))
+
+	var out []binding
+
+	for _, oid := range req.oids[:nonRepeaters] {
+		out = append(out, v.next(oid))
+	}
+
+	resp := a.response(req, errorNone, 0, out)
+	if len(resp) > a.MaxMessageSize {
+		return a.response(req, errorTooBig, 0, nil)
+	}
+
+	last := slices.Clone(req.oids[nonRepeaters:])
+
+	for range maxRepetitions {
+		if len(last) == 0 {
+			break
+		}
+
+		done := true
+		batch := make([]binding, 0, len(last))
+
+		for i, oid := range last {
+			b := v.next(oid)
+			batch = append(batch, b)
+			last[i] = b.oid
+
+			if b.value.tag != tagEndOfMibView {
+				done = false
+			}
+		}
+
+		next := a.response(req, errorNone, 0, append(slices.Clip(out), batch...))
+		if len(next) > a.MaxMessageSize {
+			break
+		}
+
+		out, resp = append(out, batch...), next
+
+		if done {
+			break
+		}
+	}
+
+	return resp
+}
+
+// responseBindings echoes the requested OIDs with a value, or NULL if value is nil.
+func responseBindings(oids []OID, v *value) []binding {
+	if v == nil {
+		v = &value{tag: tagNull}
+	}
+
+	out := make([]binding, len(oids))
+	for i, oid := range oids {
+		out[i] = binding{oid, v}
+	}
+
+	return out
+}
+
+func (a *Agent) response(req *request, status, index int64, bindings []binding) []byte {
+	var list encoder
+
+	for _, b := range bindings {
+		var vb encoder
+
+		vb = vb.oid(b.oid)
+		list = list.tlv(tagSequence, encodeValue(vb, b.value))
+	}
+
+	var pdu encoder
+
+	pdu = pdu.integer(tagInteger, req.id).integer(tagInteger, status).integer(tagInteger, index).
+		tlv(tagSequence, list)
+
+	var msg encoder
+
+	msg = msg.integer(tagInteger, snmpVersion2c).tlv(tagOctetString, req.community).tlv(tagResponse, pdu)
+
+	var out encoder
+
+	return out.tlv(tagSequence, msg)
+}
+
+func encodeValue(e encoder, v *value) encoder {
+	switch v.tag {
+	case tagInteger:
+		return e.integer(tagInteger, v.num)
+	case tagGauge32, tagCounter32, tagTimeTicks:
+		return e.unsigned(v.tag, uint64(v.num))
+	case tagOctetString:
+		return e.tlv(tagOctetString, []byte(v.str))
+	case tagObjectID:
+		return e.oid(v.oid)
+	default:
+		return e.tlv(v.tag, nil)
+	}
+}
+
+// view is the sorted list of all object instances served for a request.
+type view []binding
+
+func (a *Agent) view(snap *snapshot.Snapshot) view {
+	uptime := time.Since(a.start) / centisecond
+
+	system := []binding{
+		{oidSysDescr, octets("rpi_exporter " + version.Get().Version)},
+		{oidSysObjectID, &value{tag: tagObjectID, oid: a.Root}},
+		{oidSysUpTime, &value{tag: tagTimeTicks, num: int64(uint32(uptime))}},
+		{oidSysName, octets(a.sysName)},
+	}
+
+	return append(system, bindings(a.Root, snap)...)
+}
+
+func (v view) get(oid OID) binding {
+	i, found := slices.BinarySearchFunc(v, oid, func(b binding, oid OID) int { return slices.Compare(b.oid, oid) })
+	if found {
+		return v[i]
+	}
+
+	// An instance of a known object is missing, rather than the object itself.
+	for _, b := range v {
+		if len(b.oid) > 1 && oid.HasPrefix(b.oid[:len(b.oid)-1]) {
+			return binding{oid, &value{tag: tagNoSuchInstance}}
+		}
+	}
+
+	return binding{oid, &value{tag: tagNoSuchObject}}
+}
+
+func (v view) next(oid OID) binding {
+	i := sort.Search(len(v), func(i int) bool { return slices.Compare(v[i].oid, oid) > 0 })
+	if i < len(v) {
+		return v[i]
+	}
+
+	return binding{oid, &value{tag: tagEndOfMibView}}
+}
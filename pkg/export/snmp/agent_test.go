@@ -0,0 +1,368 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCommunity = "public"
+	testRequestID = 4242
+)
+
+var (
+	errTestCollect = errors.New("collect failed")
+
+	oidTemperature = DefaultRoot.Append(arcObjects, 2, 1, arcScalar)
+	oidArmRate     = DefaultRoot.Append(arcObjects, 4, arcEntry, 3, 3)
+)
+
+func testSnapshot() *snapshot.Snapshot {
+	return &snapshot.Snapshot{
+		Board: snapshot.Board{
+			Model:    snapshot.Reading[uint32]{Value: 0},
+			Revision: snapshot.Reading[uint32]{Value: 0xa03111},
+			Serial:   snapshot.Reading[string]{Value: "10000000abcdef01"},
+		},
+		Temperature: snapshot.Temperature{
+			Celsius:    snapshot.Reading[float32]{Value: 48.312},
+			MaxCelsius: snapshot.Reading[float32]{Err: errTestCollect},
+		},
+		Clocks: map[string]snapshot.Clock{
+			"arm":  {RateHz: snapshot.Reading[int]{Value: 1500000000}, MeasuredHz: snapshot.Reading[int]{Value: 1499000000}},
+			"core": {RateHz: snapshot.Reading[int]{Value: 500000000}, MeasuredHz: snapshot.Reading[int]{Err: errTestCollect}},
+		},
+	}
+}
+
+func newTestAgent() *Agent {
+	agent := NewAgent(testCommunity, func() (*snapshot.Snapshot, error) { return testSnapshot(), nil })
+	agent.sysName = "pi"
+
+	return agent
+}
+
+// encodeRequest encodes an SNMPv2c request. For GetBulkRequest, a and b are the non-repeaters and
+// max-repetitions, for other requests they are zero.
+func encodeRequest(community string, tag byte, a, b int64, oids ...OID) []byte {
+	var list encoder
+
+	for _, oid := range oids {
+		var vb encoder
+
+		list = list.tlv(tagSequence, vb.oid(oid).tlv(tagNull, nil))
+	}
+
+	var pdu encoder
+
+	pdu = pdu.integer(tagInteger, testRequestID).integer(tagInteger, a).integer(tagInteger, b).tlv(tagSequence, list)
+
+	var msg encoder
+
+	msg = msg.integer(tagInteger, snmpVersion2c).tlv(tagOctetString, []byte(community)).tlv(tag, pdu)
+
+	var out encoder
+
+	return out.tlv(tagSequence, msg)
+}
+
+// response is a decoded response PDU.
+type response struct {
+	status, index int64
+	bindings      []testBinding
+}
+
+type testBinding struct {
+	oid OID
+	tag byte
+	// num is set for integer types, str for octet strings.
+	num int64
+	str string
+}
+
+func decodeResponse(t *testing.T, msg []byte) response {
+	t.Helper()
+
+	d := decoder(msg)
+	content, err := d.expect(tagSequence)
+	require.NoError(t, err)
+	require.Empty(t, d)
+
+	d = decoder(content)
+	version, err := d.integer()
+	require.NoError(t, err)
+	assert.Equal(t, int64(snmpVersion2c), version)
+
+	community, err := d.expect(tagOctetString)
+	require.NoError(t, err)
+	assert.Equal(t, testCommunity, string(community))
+
+	pdu, err := d.expect(tagResponse)
+	require.NoError(t, err)
+
+	d = decoder(pdu)
+	id, err := d.integer()
+	require.NoError(t, err)
+	assert.Equal(t, int64(testRequestID), id)
+
+	var resp response
+
+	resp.status, err = d.integer()
+	require.NoError(t, err)
+	resp.index, err = d.integer()
+	require.NoError(t, err)
+
+	list, err := d.expect(tagSequence)
+	require.NoError(t, err)
+
+	for d = decoder(list); len(d) > 0; {
+		vb, err := d.expect(tagSequence)
+		require.NoError(t, err)
+
+		vbd := decoder(vb)
+		oid, err := vbd.oid()
+		require.NoError(t, err)
+
+		tag, content, err := vbd.next()
+		require.NoError(t, err)
+
+		b := testBinding{oid: oid, tag: tag}
+
+		switch tag {
+		case tagOctetString:
+			b.str = string(content)
+		case tagInteger, tagGauge32, tagCounter32, tagTimeTicks:
+			for _, c := range content {
+				b.num = b.num<<byteBits | int64(c)
+			}
+		}
+
+		resp.bindings = append(resp.bindings, b)
+	}
+
+	return resp
+}
+
+func handleTest(t *testing.T, agent *Agent, req []byte) response {
+	t.Helper()
+
+	msg, err := agent.handle(req)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(msg), agent.MaxMessageSize)
+
+	return decodeResponse(t, msg)
+}
+
+func TestGet(t *testing.T) {
+	resp := handleTest(t, newTestAgent(), encodeRequest(testCommunity, tagGetRequest, 0, 0,
+		oidSysName,
+		oidTemperature,
+		oidArmRate,
+		DefaultRoot.Append(arcObjects, 2, 1, 1),
+		OID{1, 3, 6, 1, 2, 1, 99, 0},
+	))
+
+	assert.Equal(t, int64(errorNone), resp.status)
+	assert.Equal(t, []testBinding{
+		{oid: oidSysName, tag: tagOctetString, str: "pi"},
+		{oid: oidTemperature, tag: tagInteger, num: 48312},
+		{oid: oidArmRate, tag: tagGauge32, num: 1500000000},
+		{oid: DefaultRoot.Append(arcObjects, 2, 1, 1), tag: tagNoSuchInstance},
+		{oid: OID{1, 3, 6, 1, 2, 1, 99, 0}, tag: tagNoSuchObject},
+	}, resp.bindings)
+}
+
+func TestGetBoardSerialNotServed(t *testing.T) {
+	oidSerial := DefaultRoot.Append(arcObjects, 1, 3, arcScalar)
+
+	resp := handleTest(t, newTestAgent(), encodeRequest(testCommunity, tagGetRequest, 0, 0, oidSerial))
+
+	assert.Equal(t, []testBinding{{oid: oidSerial, tag: tagNoSuchObject}}, resp.bindings)
+}
+
+func TestGetNextWalk(t *testing.T) {
+	agent := newTestAgent()
+	want := agent.view(testSnapshot())
+
+	var walked []OID
+
+	for oid := (OID{1, 3}); ; {
+		resp := handleTest(t, agent, encodeRequest(testCommunity, tagGetNextRequest, 0, 0, oid))
+		require.Len(t, resp.bindings, 1)
+
+		b := resp.bindings[0]
+		if b.tag == tagEndOfMibView {
+			assert.Equal(t, oid, b.oid)
+
+			break
+		}
+
+		require.Positive(t, slices.Compare(b.oid, oid), "walk must advance")
+
+		walked = append(walked, b.oid)
+		oid = b.oid
+	}
+
+	require.Len(t, walked, len(want))
+
+	for i, b := range want {
+		assert.Equal(t, b.oid, walked[i])
+	}
+
+	// The walk covers the system group, then the objects in order, skipping failed readings.
+	assert.Equal(t, oidSysDescr, walked[0])
+	assert.Contains(t, walked, oidArmRate)
+	assert.NotContains(t, walked, DefaultRoot.Append(arcObjects, 2, 2, arcScalar))
+	assert.NotContains(t, walked, DefaultRoot.Append(arcObjects, 4, arcEntry, 4, 4))
+}
+
+func TestGetBulk(t *testing.T) {
+	agent := newTestAgent()
+	view := agent.view(testSnapshot())
+
+	resp := handleTest(t, agent, encodeRequest(testCommunity, tagGetBulkRequest, 1, 3,
+		OID{1, 3, 6, 1, 2, 1, 1},
+		oidTemperature,
+		view[len(view)-2].oid,
+	))
+
+	assert.Equal(t, int64(errorNone), resp.status)
+
+	oids := make([]OID, len(resp.bindings))
+	for i, b := range resp.bindings {
+		oids[i] = b.oid
+	}
+
+	i := slices.IndexFunc(view, func(b binding) bool { return slices.Equal(b.oid, oidTemperature) })
+
+	// The non-repeater once, then the repeaters interleaved for every repetition. The second repeater
+	// reaches the end of the view, which is repeated until the first one is done as well.
+	last := view[len(view)-1].oid
+	assert.Equal(t, []OID{
+		oidSysDescr,
+		view[i+1].oid, last,
+		view[i+2].oid, last,
+		view[i+3].oid, last,
+	}, oids)
+	assert.Equal(t, byte(tagGauge32), resp.bindings[2].tag)
+	assert.Equal(t, byte(tagEndOfMibView), resp.bindings[4].tag)
+	assert.Equal(t, byte(tagEndOfMibView), resp.bindings[6].tag)
+}
+
+func TestGetBulkTruncated(t *testing.T) {
+	agent := newTestAgent()
+	agent.MaxMessageSize = 200
+
+	resp := handleTest(t, agent, encodeRequest(testCommunity, tagGetBulkRequest, 0, 1000, OID{1, 3}))
+
+	// As many repetitions as fit, rather than tooBig.
+	assert.Equal(t, int64(errorNone), resp.status)
+	assert.NotEmpty(t, resp.bindings)
+	assert.Less(t, len(resp.bindings), len(agent.view(testSnapshot())))
+}
+
+func TestTooBig(t *testing.T) {
+	agent := newTestAgent()
+	agent.MaxMessageSize = 100
+
+	oids := make([]OID, 0, 10)
+	for range 10 {
+		oids = append(oids, oidSysName)
+	}
+
+	resp := handleTest(t, agent, encodeRequest(testCommunity, tagGetRequest, 0, 0, oids...))
+	assert.Equal(t, int64(errorTooBig), resp.status)
+	assert.Empty(t, resp.bindings)
+
+	// The non-repeaters of a bulk request must fit as a whole.
+	resp = handleTest(t, agent, encodeRequest(testCommunity, tagGetBulkRequest, 10, 1, oids...))
+	assert.Equal(t, int64(errorTooBig), resp.status)
+}
+
+func TestSetNotWritable(t *testing.T) {
+	resp := handleTest(t, newTestAgent(), encodeRequest(testCommunity, tagSetRequest, 0, 0, oidTemperature))
+
+	assert.Equal(t, int64(errorNotWritable), resp.status)
+	assert.Equal(t, int64(1), resp.index)
+	assert.Equal(t, []testBinding{{oid: oidTemperature, tag: tagNull}}, resp.bindings)
+}
+
+func TestCollectError(t *testing.T) {
+	agent := NewAgent(testCommunity, func() (*snapshot.Snapshot, error) { return nil, errTestCollect })
+
+	resp := handleTest(t, agent, encodeRequest(testCommunity, tagGetRequest, 0, 0, oidTemperature))
+	assert.Equal(t, int64(errorGeneral), resp.status)
+}
+
+func TestDroppedRequests(t *testing.T) {
+	agent := newTestAgent()
+
+	for name, req := range map[string][]byte{
+		"wrong community": encodeRequest("private", tagGetRequest, 0, 0, oidTemperature),
+		"response pdu":    encodeRequest(testCommunity, tagResponse, 0, 0, oidTemperature),
+		"truncated":       encodeRequest(testCommunity, tagGetRequest, 0, 0, oidTemperature)[:20],
+		"snmpv1": append(encoder{}, encoder{}.tlv(tagSequence,
+			encoder{}.integer(tagInteger, 0).tlv(tagOctetString, []byte(testCommunity)))...),
+	} {
+		_, err := agent.handle(req)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestServe(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- newTestAgent().Serve(ctx, conn) }()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+
+	defer client.Close()
+
+	// A request with the wrong community is dropped without an answer.
+	_, err = client.Write(encodeRequest("private", tagGetRequest, 0, 0, oidSysName))
+	require.NoError(t, err)
+
+	_, err = client.Write(encodeRequest(testCommunity, tagGetRequest, 0, 0, oidSysName))
+	require.NoError(t, err)
+
+	require.NoError(t, client.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	buf := make([]byte, maxPacketSize)
+	n, err := client.Read(buf)
+	require.NoError(t, err)
+
+	resp := decodeResponse(t, buf[:n])
+	assert.Equal(t, []testBinding{{oid: oidSysName, tag: tagOctetString, str: "pi"}}, resp.bindings)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func FuzzHandle(f *testing.F) {
+	f.Add(encodeRequest(testCommunity, tagGetRequest, 0, 0, oidSysName))
+	f.Add(encodeRequest(testCommunity, tagGetNextRequest, 0, 0, DefaultRoot))
+	f.Add(encodeRequest(testCommunity, tagGetBulkRequest, 1, 5, oidSysName, oidTemperature))
+	f.Add(encodeRequest(testCommunity, tagSetRequest, 0, 0, oidTemperature))
+
+	agent := newTestAgent()
+
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		resp, err := agent.handle(msg)
+		if err == nil && len(resp) > agent.MaxMessageSize {
+			t.Fatalf("response of %d bytes exceeds the maximum message size", len(resp))
+		}
+	})
+}
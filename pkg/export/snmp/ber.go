@@ -0,0 +1,262 @@
+package snmp
+
+// SNMP messages are encoded with the Basic Encoding Rules of ASN.1. Only the subset used by SNMPv2c
+// is implemented, see RFC 3416:
+//
+// https://www.rfc-editor.org/rfc/rfc3416
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BER and SNMP application tags.
+const (
+	tagInteger        = 0x02
+	tagOctetString    = 0x04
+	tagNull           = 0x05
+	tagObjectID       = 0x06
+	tagSequence       = 0x30
+	tagCounter32      = 0x41
+	tagGauge32        = 0x42
+	tagTimeTicks      = 0x43
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+
+	tagGetRequest     = 0xa0
+	tagGetNextRequest = 0xa1
+	tagResponse       = 0xa2
+	tagSetRequest     = 0xa3
+	tagGetBulkRequest = 0xa5
+)
+
+const (
+	lengthLongForm = 0x80
+	oidFirstArcs   = 40
+	base128Bits    = 7
+	base128More    = 0x80
+	byteBits       = 8
+	maxLengthBytes = 4
+	signBit        = 0x80
+)
+
+var errTruncated = errors.New("truncated message")
+
+// OID is an object identifier.
+type OID []uint32
+
+// ParseOID parses a dotted object identifier such as 1.3.6.1.4.1.
+func ParseOID(s string) (OID, error) {
+	var oid OID
+
+	for _, arc := range strings.Split(strings.TrimPrefix(s, "."), ".") {
+		n, err := strconv.ParseUint(arc, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid oid %q: %w", s, err)
+		}
+
+		oid = append(oid, uint32(n))
+	}
+
+	if len(oid) < 2 {
+		return nil, fmt.Errorf("invalid oid %q: at least two arcs are required", s)
+	}
+
+	return oid, nil
+}
+
+func (o OID) String() string {
+	arcs := make([]string, len(o))
+	for i, arc := range o {
+		arcs[i] = strconv.FormatUint(uint64(arc), 10)
+	}
+
+	return strings.Join(arcs, ".")
+}
+
+// Append returns a new OID with arcs appended.
+func (o OID) Append(arcs ...uint32) OID {
+	return append(slices.Clip(o), arcs...)
+}
+
+// HasPrefix reports whether o starts with prefix.
+func (o OID) HasPrefix(prefix OID) bool {
+	return len(o) >= len(prefix) && slices.Equal(o[:len(prefix)], prefix)
+}
+
+// encoder appends BER encoded values.
+type encoder []byte
+
+func (e encoder) tlv(tag byte, content []byte) encoder {
+	e = append(e, tag)
+
+	switch n := len(content); {
+	case n < lengthLongForm:
+		e = append(e, byte(n))
+	default:
+		var length []byte
+		for ; n > 0; n >>= byteBits {
+			length = append([]byte{byte(n)}, length...)
+		}
+
+		e = append(e, lengthLongForm|byte(len(length)))
+		e = append(e, length...)
+	}
+
+	return append(e, content...)
+}
+
+func (e encoder) integer(tag byte, v int64) encoder {
+	return e.tlv(tag, minimal(binary.BigEndian.AppendUint64(nil, uint64(v))))
+}
+
+func (e encoder) unsigned(tag byte, v uint64) encoder {
+	// The leading zero keeps a value with the high bit set positive.
+	return e.tlv(tag, minimal(binary.BigEndian.AppendUint64([]byte{0}, v)))
+}
+
+// minimal strips leading bytes of a two's complement integer that only repeat the sign bit.
+func minimal(b []byte) []byte {
+	for len(b) > 1 && (b[0] == 0 && b[1]&signBit == 0 || b[0] == 0xff && b[1]&signBit != 0) {
+		b = b[1:]
+	}
+
+	return b
+}
+
+func (e encoder) oid(oid OID) encoder {
+	var b []byte
+
+	if len(oid) >= 2 {
+		b = appendBase128(b, oid[0]*oidFirstArcs+oid[1])
+
+		for _, arc := range oid[2:] {
+			b = appendBase128(b, arc)
+		}
+	}
+
+	return e.tlv(tagObjectID, b)
+}
+
+func appendBase128(b []byte, v uint32) []byte {
+	var out []byte
+
+	out = append(out, byte(v&(base128More-1)))
+	for v >>= base128Bits; v > 0; v >>= base128Bits {
+		out = append([]byte{byte(v&(base128More-1)) | base128More}, out...)
+	}
+
+	return append(b, out...)
+}
+
+// decoder reads BER encoded values.
+type decoder []byte
+
+// next reads a value and returns its tag and content.
+func (d *decoder) next() (byte, []byte, error) {
+	b := *d
+	if len(b) < 2 {
+		return 0, nil, errTruncated
+	}
+
+	tag, n := b[0], int(b[1])
+	b = b[2:]
+
+	if n&lengthLongForm != 0 {
+		size := n &^ lengthLongForm
+		if size == 0 || size > maxLengthBytes || len(b) < size {
+			return 0, nil, errors.New("invalid length")
+		}
+
+		n = 0
+		for _, c := range b[:size] {
+			n = n<<byteBits | int(c)
+		}
+
+		b = b[size:]
+	}
+
+	if n < 0 || len(b) < n {
+		return 0, nil, errTruncated
+	}
+
+	*d = b[n:]
+
+	return tag, b[:n], nil
+}
+
+// expect reads a value with the given tag.
+func (d *decoder) expect(tag byte) ([]byte, error) {
+	t, content, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if t != tag {
+		return nil, fmt.Errorf("unexpected tag 0x%02x, expected 0x%02x", t, tag)
+	}
+
+	return content, nil
+}
+
+func (d *decoder) integer() (int64, error) {
+	b, err := d.expect(tagInteger)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(b) == 0 || len(b) > byteBits {
+		return 0, errors.New("invalid integer")
+	}
+
+	v := int64(int8(b[0]))
+	for _, c := range b[1:] {
+		v = v<<byteBits | int64(c)
+	}
+
+	return v, nil
+}
+
+func (d *decoder) oid() (OID, error) {
+	b, err := d.expect(tagObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		oid OID
+		v   uint64
+	)
+
+	for i, c := range b {
+		v = v<<base128Bits | uint64(c&(base128More-1))
+		if v > math.MaxUint32 {
+			return nil, errors.New("invalid oid")
+		}
+
+		if c&base128More != 0 {
+			if i == len(b)-1 {
+				return nil, errors.New("invalid oid")
+			}
+
+			continue
+		}
+
+		if len(oid) == 0 {
+			first := min(uint32(v)/oidFirstArcs, 2)
+			oid = append(oid, first, uint32(v)-first*oidFirstArcs)
+		} else {
+			oid = append(oid, uint32(v))
+		}
+
+		v = 0
+	}
+
+	return oid, nil
+}
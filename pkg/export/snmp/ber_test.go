@@ -0,0 +1,160 @@
+package snmp
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeInteger(t *testing.T) {
+	tests := []struct {
+		v    int64
+		want []byte
+	}{
+		{0, []byte{0x02, 0x01, 0x00}},
+		{127, []byte{0x02, 0x01, 0x7f}},
+		{128, []byte{0x02, 0x02, 0x00, 0x80}},
+		{256, []byte{0x02, 0x02, 0x01, 0x00}},
+		{-1, []byte{0x02, 0x01, 0xff}},
+		{-128, []byte{0x02, 0x01, 0x80}},
+		{-129, []byte{0x02, 0x02, 0xff, 0x7f}},
+		{math.MaxInt32, []byte{0x02, 0x04, 0x7f, 0xff, 0xff, 0xff}},
+		{math.MinInt64, []byte{0x02, 0x08, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, []byte(encoder{}.integer(tagInteger, tt.v)), tt.v)
+
+		d := decoder(tt.want)
+		v, err := d.integer()
+		require.NoError(t, err)
+		assert.Equal(t, tt.v, v)
+		assert.Empty(t, d)
+	}
+}
+
+func TestEncodeUnsigned(t *testing.T) {
+	assert.Equal(t, []byte{0x42, 0x01, 0x00}, []byte(encoder{}.unsigned(tagGauge32, 0)))
+	assert.Equal(t, []byte{0x42, 0x01, 0x7f}, []byte(encoder{}.unsigned(tagGauge32, 127)))
+	// Values with the high bit set get a leading zero to stay positive.
+	assert.Equal(t, []byte{0x42, 0x02, 0x00, 0x80}, []byte(encoder{}.unsigned(tagGauge32, 128)))
+	assert.Equal(t, []byte{0x43, 0x05, 0x00, 0xff, 0xff, 0xff, 0xff},
+		[]byte(encoder{}.unsigned(tagTimeTicks, math.MaxUint32)))
+}
+
+func TestEncodeLength(t *testing.T) {
+	for _, tt := range []struct {
+		n      int
+		header []byte
+	}{
+		{0, []byte{0x04, 0x00}},
+		{127, []byte{0x04, 0x7f}},
+		{128, []byte{0x04, 0x81, 0x80}},
+		{255, []byte{0x04, 0x81, 0xff}},
+		{256, []byte{0x04, 0x82, 0x01, 0x00}},
+		{70000, []byte{0x04, 0x83, 0x01, 0x11, 0x70}},
+	} {
+		content := bytes.Repeat([]byte{'x'}, tt.n)
+		encoded := encoder{}.tlv(tagOctetString, content)
+		assert.Equal(t, tt.header, []byte(encoded[:len(tt.header)]), tt.n)
+
+		d := decoder(encoded)
+		tag, got, err := d.next()
+		require.NoError(t, err)
+		assert.Equal(t, byte(tagOctetString), tag)
+		assert.Equal(t, content, got)
+	}
+}
+
+func TestEncodeOID(t *testing.T) {
+	tests := []struct {
+		oid  OID
+		want []byte
+	}{
+		{OID{1, 3, 6, 1, 4, 1, 8072}, []byte{0x06, 0x07, 0x2b, 0x06, 0x01, 0x04, 0x01, 0xbf, 0x08}},
+		{OID{1, 3, 6, 1, 2, 1, 1, 1, 0}, []byte{0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00}},
+		{OID{2, 999, 3}, []byte{0x06, 0x03, 0x88, 0x37, 0x03}},
+		{OID{1, 3, math.MaxUint32}, []byte{0x06, 0x06, 0x2b, 0x8f, 0xff, 0xff, 0xff, 0x7f}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, []byte(encoder{}.oid(tt.oid)), tt.oid.String())
+
+		d := decoder(tt.want)
+		oid, err := d.oid()
+		require.NoError(t, err)
+		assert.Equal(t, tt.oid, oid)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		read func(*decoder) error
+	}{
+		{"empty", nil, decodeNext},
+		{"truncated content", []byte{0x04, 0x05, 'a'}, decodeNext},
+		{"indefinite length", []byte{0x04, 0x80, 'a', 0, 0}, decodeNext},
+		{"length too long", []byte{0x04, 0x85, 1, 1, 1, 1, 1}, decodeNext},
+		{"truncated long length", []byte{0x04, 0x82, 0x01}, decodeNext},
+		{"wrong tag", []byte{0x04, 0x01, 0x00}, decodeInteger},
+		{"empty integer", []byte{0x02, 0x00}, decodeInteger},
+		{"integer too long", []byte{0x02, 0x09, 1, 0, 0, 0, 0, 0, 0, 0, 0}, decodeInteger},
+		{"oid continuation at end", []byte{0x06, 0x02, 0x2b, 0x81}, decodeOID},
+		{"oid arc overflow", []byte{0x06, 0x07, 0x2b, 0x90, 0x80, 0x80, 0x80, 0x80, 0x00}, decodeOID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := decoder(tt.b)
+			assert.Error(t, tt.read(&d))
+		})
+	}
+}
+
+func decodeNext(d *decoder) error {
+	_, _, err := d.next()
+
+	return err
+}
+
+func decodeInteger(d *decoder) error {
+	_, err := d.integer()
+
+	return err
+}
+
+func decodeOID(d *decoder) error {
+	_, err := d.oid()
+
+	return err
+}
+
+func TestParseOID(t *testing.T) {
+	oid, err := ParseOID(".1.3.6.1.4.1.8072.9999.9999.31415")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRoot, oid)
+	assert.Equal(t, "1.3.6.1.4.1.8072.9999.9999.31415", oid.String())
+
+	for _, s := range []string{"", "1", "1.3.x", "1..3", "1.3.4294967296"} {
+		_, err := ParseOID(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestOIDAppendDoesNotAlias(t *testing.T) {
+	base := make(OID, 2, 8)
+	copy(base, OID{1, 3})
+
+	a := base.Append(6)
+	b := base.Append(7)
+
+	assert.Equal(t, OID{1, 3, 6}, a)
+	assert.Equal(t, OID{1, 3, 7}, b)
+	assert.True(t, a.HasPrefix(base))
+	assert.False(t, base.HasPrefix(a))
+}
@@ -0,0 +1,328 @@
+package snmp
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/snapshot"
+)
+
+// DefaultRoot is the root of RPI-EXPORTER-MIB. It is a placeholder below netSnmpPlaypen, the arc
+// net-snmp sets aside for local experiments, because the project has no private enterprise number of
+// its own. Deployments should move the tree below their own enterprise number with Agent.Root and
+// WriteMIB.
+var DefaultRoot = OID{1, 3, 6, 1, 4, 1, 8072, 9999, 9999, 31415}
+
+// Arcs below the root.
+const (
+	arcObjects     = 1
+	arcConformance = 2
+	arcEntry       = 1
+	arcIndex       = 1
+	arcScalar      = 0
+)
+
+const (
+	millis = 1000
+
+	// TruthValue encodes booleans as 1 and 2.
+	truthTrue  = 1
+	truthFalse = 2
+)
+
+// syntax is the type of an object in the MIB and on the wire.
+type syntax struct {
+	name string
+	// refinement restricts the range or size of name in the object definition.
+	refinement string
+	tag        byte
+}
+
+var (
+	syntaxInteger32     = syntax{name: "Integer32", tag: tagInteger}
+	syntaxIndex         = syntax{name: "Integer32", refinement: "(1..2147483647)", tag: tagInteger}
+	syntaxGauge32       = syntax{name: "Gauge32", tag: tagGauge32}
+	syntaxUnsigned32    = syntax{name: "Unsigned32", tag: tagGauge32}
+	syntaxDisplayString = syntax{name: "DisplayString", refinement: "(SIZE (0..255))", tag: tagOctetString}
+	syntaxTruthValue    = syntax{name: "TruthValue", tag: tagInteger}
+)
+
+// value is the value of an object instance.
+type value struct {
+	tag byte
+	num int64
+	str string
+	oid OID
+}
+
+func integer(v int64) *value { return &value{tag: tagInteger, num: v} }
+func gauge(v uint32) *value  { return &value{tag: tagGauge32, num: int64(v)} }
+func octets(v string) *value { return &value{tag: tagOctetString, str: v} }
+
+func truth(v bool) *value {
+	if v {
+		return integer(truthTrue)
+	}
+
+	return integer(truthFalse)
+}
+
+// reading returns the value of a reading, or nil if it could not be read.
+func reading[T any](r snapshot.Reading[T], convert func(T) *value) *value {
+	if !r.OK() {
+		return nil
+	}
+
+	return convert(r.Value)
+}
+
+// object is a scalar or table column of the MIB.
+type object struct {
+	name        string
+	arc         uint32
+	syntax      syntax
+	units       string
+	description string
+}
+
+// scalar is an object with a single instance.
+type scalar struct {
+	object
+	value func(*snapshot.Snapshot) *value
+}
+
+// group is a node of related scalars.
+type group struct {
+	name    string
+	arc     uint32
+	scalars []scalar
+}
+
+// row is a table row. values holds the value of each column but the index, nil if missing.
+type row struct {
+	index  uint32
+	values []*value
+}
+
+// table is a conceptual table indexed by its first column.
+type table struct {
+	name        string
+	arc         uint32
+	description string
+	entry       string
+	// columns starts with the index column.
+	columns []object
+	rows    func(*snapshot.Snapshot) []row
+}
+
+// entryType returns the name of the SEQUENCE type of a table entry.
+func (t table) entryType() string {
+	return strings.ToUpper(t.entry[:1]) + t.entry[1:]
+}
+
+// groups and tables are the registry of all objects, from which both the agent and the MIB are
+// built. Arcs must never be reused once published.
+var (
+	groups = []group{boardGroup, thermalGroup, throttleGroup}
+	tables = []table{clockTable, voltageTable}
+)
+
+var boardGroup = group{name: "rpiBoard", arc: 1, scalars: []scalar{
+	{
+		object{"rpiBoardModel", 1, syntaxUnsigned32, "", "Model number of the system board."},
+		func(s *snapshot.Snapshot) *value { return reading(s.Board.Model, gauge) },
+	},
+	{
+		object{"rpiBoardRevision", 2, syntaxUnsigned32, "", "Revision code of the system board."},
+		func(s *snapshot.Snapshot) *value { return reading(s.Board.Revision, gauge) },
+	},
+	// Arc 3 held the board serial. It is not served, because it identifies the device and SNMPv2c
+	// sends the community in clear text.
+	{
+		object{"rpiFirmwareRevision", 4, syntaxUnsigned32, "", "Revision of the VideoCore firmware."},
+		func(s *snapshot.Snapshot) *value { return reading(s.Board.FirmwareRevision, gauge) },
+	},
+	{
+		object{"rpiFirmwareVariant", 5, syntaxDisplayString, "", "Variant of the running VideoCore firmware."},
+		func(s *snapshot.Snapshot) *value { return reading(s.Board.FirmwareVariant, octets) },
+	},
+	{
+		object{"rpiFirmwareHash", 6, syntaxDisplayString, "", "Git hash of the running VideoCore firmware."},
+		func(s *snapshot.Snapshot) *value { return reading(s.Board.FirmwareHash, octets) },
+	},
+}}
+
+func millisValue(v float32) *value {
+	return integer(int64(math.Round(float64(v) * millis)))
+}
+
+var thermalGroup = group{name: "rpiThermal", arc: 2, scalars: []scalar{
+	{
+		object{"rpiTemperature", 1, syntaxInteger32, "millidegrees Celsius", "Temperature of the SoC."},
+		func(s *snapshot.Snapshot) *value { return reading(s.Temperature.Celsius, millisValue) },
+	},
+	{
+		object{"rpiTemperatureMax", 2, syntaxInteger32, "millidegrees Celsius", "Maximum safe temperature of the SoC."},
+		func(s *snapshot.Snapshot) *value { return reading(s.Temperature.MaxCelsius, millisValue) },
+	},
+}}
+
+var throttleGroup = group{name: "rpiThrottle", arc: 3, scalars: throttleScalars()}
+
+// throttleScalars returns the throttled bitmask, a flag per active and occurred throttle condition
+// and the turbo state.
+func throttleScalars() []scalar {
+	scalars := []scalar{{
+		object{"rpiThrottledFlags", 1, syntaxUnsigned32, "", "Throttled bitmask as returned by GET_THROTTLED."},
+		func(s *snapshot.Snapshot) *value {
+			return reading(s.Throttle.State, func(st snapshot.ThrottleState) *value { return gauge(st.Flags) })
+		},
+	}}
+
+	arc := uint32(len(scalars))
+
+	for _, occurred := range []bool{false, true} {
+		for _, cond := range snapshot.ThrottleConditions {
+			arc++
+
+			name, mask, description := "rpi"+camelCase(cond.Name), cond.Active, "Whether "+
+				strings.ReplaceAll(cond.Name, "_", " ")+" is active."
+			if occurred {
+				name, mask, description = name+"Occurred", cond.Occurred, "Whether "+
+					strings.ReplaceAll(cond.Name, "_", " ")+" occurred since boot, or since the last poll of the throttle monitor."
+			}
+
+			scalars = append(scalars, scalar{
+				object{name, arc, syntaxTruthValue, "", description},
+				func(s *snapshot.Snapshot) *value {
+					return reading(s.Throttle.State, func(st snapshot.ThrottleState) *value {
+						return truth(st.Flags&mask != 0)
+					})
+				},
+			})
+		}
+	}
+
+	return append(scalars, scalar{
+		object{"rpiTurbo", arc + 1, syntaxTruthValue, "", "Whether turbo mode is enabled."},
+		func(s *snapshot.Snapshot) *value { return reading(s.Throttle.Turbo, truth) },
+	})
+}
+
+func camelCase(s string) string {
+	words := strings.Split(s, "_")
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+
+	return strings.Join(words, "")
+}
+
+var clockTable = table{
+	name: "rpiClockTable", arc: 4, entry: "rpiClockEntry",
+	description: "Clocks of the SoC, indexed by their mailbox clock id.",
+	columns: []object{
+		{"rpiClockIndex", arcIndex, syntaxIndex, "", "Mailbox clock id."},
+		{"rpiClockName", 2, syntaxDisplayString, "", "Name of the clock."},
+		{"rpiClockRate", 3, syntaxGauge32, "Hz", "Configured rate of the clock."},
+		{"rpiClockMeasuredRate", 4, syntaxGauge32, "Hz", "Measured rate of the clock."},
+	},
+	rows: func(s *snapshot.Snapshot) []row {
+		ids := slices.Sorted(maps.Keys(snapshot.ClockLabels))
+		rows := make([]row, 0, len(ids))
+
+		for _, id := range ids {
+			name := snapshot.ClockLabels[id]
+
+			clock, ok := s.Clocks[name]
+			if !ok {
+				continue
+			}
+
+			rows = append(rows, row{index: uint32(id), values: []*value{
+				octets(name),
+				reading(clock.RateHz, hertz),
+				reading(clock.MeasuredHz, hertz),
+			}})
+		}
+
+		return rows
+	},
+}
+
+func hertz(v int) *value {
+	return gauge(uint32(max(v, 0)))
+}
+
+var voltageTable = table{
+	name: "rpiVoltageTable", arc: 5, entry: "rpiVoltageEntry",
+	description: "Voltage rails, indexed by their mailbox voltage id.",
+	columns: []object{
+		{"rpiVoltageIndex", arcIndex, syntaxIndex, "", "Mailbox voltage id."},
+		{"rpiVoltageName", 2, syntaxDisplayString, "", "Name of the voltage rail."},
+		{"rpiVoltage", 3, syntaxInteger32, "millivolts", "Current voltage of the rail."},
+		{"rpiVoltageMin", 4, syntaxInteger32, "millivolts", "Minimum supported voltage of the rail."},
+		{"rpiVoltageMax", 5, syntaxInteger32, "millivolts", "Maximum supported voltage of the rail."},
+	},
+	rows: func(s *snapshot.Snapshot) []row {
+		ids := slices.Sorted(maps.Keys(snapshot.VoltageLabels))
+		rows := make([]row, 0, len(ids))
+
+		for _, id := range ids {
+			name := snapshot.VoltageLabels[id]
+
+			voltage, ok := s.Voltages[name]
+			if !ok {
+				continue
+			}
+
+			rows = append(rows, row{index: uint32(id), values: []*value{
+				octets(name),
+				reading(voltage.Volts, millisValue),
+				reading(voltage.MinVolts, millisValue),
+				reading(voltage.MaxVolts, millisValue),
+			}})
+		}
+
+		return rows
+	},
+}
+
+// binding is an object instance with its value.
+type binding struct {
+	oid   OID
+	value *value
+}
+
+// bindings returns all object instances of a snapshot below root, sorted by OID.
+func bindings(root OID, snap *snapshot.Snapshot) []binding {
+	objects := root.Append(arcObjects)
+
+	var out []binding
+
+	for _, g := range groups {
+		for _, s := range g.scalars {
+			if v := s.value(snap); v != nil {
+				out = append(out, binding{objects.Append(g.arc, s.arc, arcScalar), v})
+			}
+		}
+	}
+
+	for _, t := range tables {
+		rows := t.rows(snap)
+
+		for i, col := range t.columns[1:] {
+			for _, r := range rows {
+				if v := r.values[i]; v != nil {
+					out = append(out, binding{objects.Append(t.arc, arcEntry, col.arc, r.index), v})
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(out, func(a, b binding) int { return slices.Compare(a.oid, b.oid) })
+
+	return out
+}
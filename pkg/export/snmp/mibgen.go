@@ -0,0 +1,203 @@
+package snmp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MIBName is the name of the MIB module.
+const MIBName = "RPI-EXPORTER-MIB"
+
+// mibRevision is the date of the last change to the registry. Bump it, and add a revision below,
+// whenever objects are added.
+const mibRevision = "202610180000Z"
+
+// playpen is the net-snmp arc for local experiments, under which DefaultRoot is a placeholder.
+const playpen = "netSnmpPlaypen"
+
+// mibParents are the nodes the root may be registered under, with the module defining them.
+var mibParents = []struct {
+	name, module string
+	oid          OID
+}{
+	{playpen, "NET-SNMP-MIB", OID{1, 3, 6, 1, 4, 1, 8072, 9999, 9999}},
+	{"enterprises", "SNMPv2-SMI", OID{1, 3, 6, 1, 4, 1}},
+}
+
+// mibWriter writes the MIB, remembering the first write error.
+type mibWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (m *mibWriter) printf(format string, a ...any) {
+	if m.err != nil {
+		return
+	}
+
+	if _, err := fmt.Fprintf(m.w, format, a...); err != nil {
+		m.err = fmt.Errorf("unable to write mib: %w", err)
+	}
+}
+
+func (m *mibWriter) objectType(o object, access, parent string) {
+	m.printf("%s OBJECT-TYPE\n", o.name)
+	m.printf("    SYNTAX      %s\n", strings.TrimSpace(o.syntax.name+" "+o.syntax.refinement))
+
+	if o.units != "" {
+		m.printf("    UNITS       \"%s\"\n", o.units)
+	}
+
+	m.printf("    MAX-ACCESS  %s\n", access)
+	m.printf("    STATUS      current\n")
+	m.printf("    DESCRIPTION\n        \"%s\"\n", o.description)
+	m.printf("    ::= { %s %d }\n\n", parent, o.arc)
+}
+
+// WriteMIB writes the SMIv2 definition of all objects served by the agent, with the module rooted
+// at root. The root must be a direct child of enterprises or netSnmpPlaypen.
+func WriteMIB(w io.Writer, root OID) error {
+	parent, module, arc := "", "", uint32(0)
+
+	for _, p := range mibParents {
+		if len(root) == len(p.oid)+1 && root.HasPrefix(p.oid) {
+			parent, module, arc = p.name, p.module, root[len(root)-1]
+
+			break
+		}
+	}
+
+	if parent == "" {
+		return fmt.Errorf("mib root %s is not a child of enterprises or netSnmpPlaypen", root)
+	}
+
+	m := &mibWriter{w: bufio.NewWriter(w)}
+
+	m.printf("%s DEFINITIONS ::= BEGIN\n\n", MIBName)
+	m.printf("-- Generated by rpi_exporter snmp_mib. Do not edit.\n\n")
+	m.printf("IMPORTS\n")
+	m.printf("    MODULE-IDENTITY, OBJECT-TYPE, Integer32, Gauge32, Unsigned32")
+
+	if module == "SNMPv2-SMI" {
+		m.printf(", %s", parent)
+	}
+
+	m.printf("\n        FROM SNMPv2-SMI\n")
+	m.printf("    DisplayString, TruthValue\n        FROM SNMPv2-TC\n")
+	m.printf("    MODULE-COMPLIANCE, OBJECT-GROUP\n        FROM SNMPv2-CONF")
+
+	if module != "SNMPv2-SMI" {
+		m.printf("\n    %s\n        FROM %s", parent, module)
+	}
+
+	m.printf(";\n\n")
+
+	m.printf("rpiExporterMIB MODULE-IDENTITY\n")
+	m.printf("    LAST-UPDATED \"%s\"\n", mibRevision)
+	m.printf("    ORGANIZATION \"rpi_exporter\"\n")
+	m.printf("    CONTACT-INFO \"https://github.com/schubergphilis/rpi_exporter\"\n")
+	m.printf("    DESCRIPTION\n        \"Hardware readings of a Raspberry Pi from the VideoCore mailbox.")
+
+	if parent == playpen {
+		m.printf("\n\n        The module is registered under netSnmpPlaypen as a placeholder,\n")
+		m.printf("        because rpi_exporter has no private enterprise number. It must\n")
+		m.printf("        not be used outside a lab: generate the MIB under an arc of your\n")
+		m.printf("        own enterprise number with rpi_exporter snmp_mib -root <oid>,\n")
+		m.printf("        and serve it with rpi_exporter snmp -root <oid>.")
+	}
+
+	m.printf("\"\n")
+	m.printf("    REVISION     \"%s\"\n", mibRevision)
+	m.printf("    DESCRIPTION\n        \"Initial version.\"\n")
+	m.printf("    ::= { %s %d }\n\n", parent, arc)
+
+	m.printf("rpiObjects     OBJECT IDENTIFIER ::= { rpiExporterMIB %d }\n", arcObjects)
+	m.printf("rpiConformance OBJECT IDENTIFIER ::= { rpiExporterMIB %d }\n\n", arcConformance)
+
+	var members []string
+
+	for _, g := range groups {
+		m.printf("%s OBJECT IDENTIFIER ::= { rpiObjects %d }\n\n", g.name, g.arc)
+
+		for _, s := range g.scalars {
+			m.objectType(s.object, "read-only", g.name)
+			members = append(members, s.name)
+		}
+	}
+
+	for _, t := range tables {
+		writeTable(m, t)
+
+		for _, col := range t.columns[1:] {
+			members = append(members, col.name)
+		}
+	}
+
+	m.printf("rpiCompliances OBJECT IDENTIFIER ::= { rpiConformance 1 }\n")
+	m.printf("rpiGroups      OBJECT IDENTIFIER ::= { rpiConformance 2 }\n\n")
+
+	m.printf("rpiCompliance MODULE-COMPLIANCE\n")
+	m.printf("    STATUS      current\n")
+	m.printf("    DESCRIPTION\n        \"The compliance statement for rpi_exporter.\"\n")
+	m.printf("    MODULE      -- this module\n")
+	m.printf("        MANDATORY-GROUPS { rpiObjectsGroup }\n")
+	m.printf("    ::= { rpiCompliances 1 }\n\n")
+
+	m.printf("rpiObjectsGroup OBJECT-GROUP\n")
+	m.printf("    OBJECTS {\n        %s\n    }\n", strings.Join(members, ",\n        "))
+	m.printf("    STATUS      current\n")
+	m.printf("    DESCRIPTION\n        \"All hardware readings of a Raspberry Pi.\"\n")
+	m.printf("    ::= { rpiGroups 1 }\n\n")
+
+	m.printf("END\n")
+
+	if m.err != nil {
+		return m.err
+	}
+
+	if err := m.w.Flush(); err != nil {
+		return fmt.Errorf("unable to write mib: %w", err)
+	}
+
+	return nil
+}
+
+func writeTable(m *mibWriter, t table) {
+	m.printf("%s OBJECT-TYPE\n", t.name)
+	m.printf("    SYNTAX      SEQUENCE OF %s\n", t.entryType())
+	m.printf("    MAX-ACCESS  not-accessible\n")
+	m.printf("    STATUS      current\n")
+	m.printf("    DESCRIPTION\n        \"%s\"\n", t.description)
+	m.printf("    ::= { rpiObjects %d }\n\n", t.arc)
+
+	m.printf("%s OBJECT-TYPE\n", t.entry)
+	m.printf("    SYNTAX      %s\n", t.entryType())
+	m.printf("    MAX-ACCESS  not-accessible\n")
+	m.printf("    STATUS      current\n")
+	m.printf("    DESCRIPTION\n        \"A row of %s.\"\n", t.name)
+	m.printf("    INDEX       { %s }\n", t.columns[0].name)
+	m.printf("    ::= { %s %d }\n\n", t.name, arcEntry)
+
+	width := 0
+	for _, col := range t.columns {
+		width = max(width, len(col.name))
+	}
+
+	fields := make([]string, 0, len(t.columns))
+	for _, col := range t.columns {
+		fields = append(fields, fmt.Sprintf("    %-*s %s", width, col.name, col.syntax.name))
+	}
+
+	m.printf("%s ::= SEQUENCE {\n%s\n}\n\n", t.entryType(), strings.Join(fields, ",\n"))
+
+	for i, col := range t.columns {
+		access := "read-only"
+		if i == 0 {
+			access = "not-accessible"
+		}
+
+		m.objectType(col, access, t.entry)
+	}
+}
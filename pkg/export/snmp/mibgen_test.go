@@ -0,0 +1,39 @@
+package snmp
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMIBShipped(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, WriteMIB(&buf, DefaultRoot))
+
+	shipped, err := os.ReadFile("../../../mibs/" + MIBName + ".txt")
+	require.NoError(t, err)
+	assert.Equal(t, string(shipped), buf.String(), "run make mib to regenerate the shipped MIB")
+	assert.Contains(t, buf.String(), "registered under netSnmpPlaypen as a placeholder")
+}
+
+func TestWriteMIBEnterpriseRoot(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, WriteMIB(&buf, OID{1, 3, 6, 1, 4, 1, 99999}))
+
+	mib := buf.String()
+	assert.Contains(t, mib, "Unsigned32, enterprises\n        FROM SNMPv2-SMI")
+	assert.Contains(t, mib, "::= { enterprises 99999 }")
+	assert.NotContains(t, mib, "netSnmpPlaypen")
+	assert.NotContains(t, mib, "placeholder")
+}
+
+func TestWriteMIBInvalidRoot(t *testing.T) {
+	for _, root := range []OID{{1, 3, 6, 1, 4, 1}, {1, 3, 6, 1, 4, 1, 99999, 1}, {1, 3, 6, 1, 2, 1, 1}} {
+		assert.Error(t, WriteMIB(&bytes.Buffer{}, root), root.String())
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Package statsd emits samples as StatsD gauges over UDP, with tags in the DogStatsD or InfluxDB
+(Telegraf) style, or folded into the metric name for plain StatsD servers. The DogStatsD datagram
+format is documented here:
+
+https://docs.datadoghq.com/developers/dogstatsd/datagram_shell/
+*/
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+)
+
+// TagStyle selects how labels are encoded.
+type TagStyle string
+
+const (
+	// TagsNone appends label values to the metric name, separated by dots.
+	TagsNone TagStyle = "none"
+	// TagsDogStatsD appends tags as |#name:value,...
+	TagsDogStatsD TagStyle = "dogstatsd"
+	// TagsInflux appends tags to the metric name as ,name=value,...
+	TagsInflux TagStyle = "influx"
+)
+
+// TagStyles lists the supported tag styles.
+var TagStyles = []TagStyle{TagsNone, TagsDogStatsD, TagsInflux}
+
+// DefaultMaxPacketSize keeps datagrams below the common Ethernet MTU after IP and UDP headers.
+const DefaultMaxPacketSize = 1432
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// invalidTagChars holds characters that separate fields, tags or metrics in any of the styles.
+var invalidTagChars = regexp.MustCompile(`[:|@#,=\s]`)
+
+// Client sends samples to a StatsD server.
+type Client struct {
+	// Prefix is prepended to every metric name, separated by a dot.
+	Prefix string
+	// Tags selects how labels are encoded.
+	Tags TagStyle
+	// MaxPacketSize is the maximum size of a datagram. The lines of a sample are never split across
+	// datagrams.
+	MaxPacketSize int
+
+	conn net.Conn
+}
+
+// Dial returns a client sending to the StatsD server at addr, a host:port pair.
+func Dial(addr string, tags TagStyle) (*Client, error) {
+	if !slices.Contains(TagStyles, tags) {
+		return nil, fmt.Errorf("unknown tag style %q", tags)
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to statsd: %w", err)
+	}
+
+	return &Client{Tags: tags, MaxPacketSize: DefaultMaxPacketSize, conn: conn}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Send sends samples as gauges, packing as many lines into each datagram as fit.
+func (c *Client) Send(samples []prometheus.Sample) error {
+	var (
+		packet []byte
+		errs   []error
+	)
+
+	flush := func() {
+		if len(packet) == 0 {
+			return
+		}
+
+		if _, err := c.conn.Write(packet); err != nil {
+			errs = append(errs, fmt.Errorf("unable to send metrics: %w", err))
+		}
+
+		packet = packet[:0]
+	}
+
+	for _, s := range samples {
+		// StatsD has no representation of NaN or infinity.
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+
+		// The lines of a sample go into the same datagram, so that the reset sent before a negative
+		// value is never applied after it.
+		lines := strings.Join(c.lines(s), "\n")
+
+		if len(packet) > 0 && len(packet)+1+len(lines) > c.MaxPacketSize {
+			flush()
+		}
+
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+
+		packet = append(packet, lines...)
+	}
+
+	flush()
+
+	return errors.Join(errs...)
+}
+
+// lines encodes a sample as gauge lines. A negative value is sent as a reset to zero followed by
+// the value, because a signed gauge value is a relative change in StatsD.
+func (c *Client) lines(s prometheus.Sample) []string {
+	name := s.Name
+	if c.Prefix != "" {
+		name = c.Prefix + "." + name
+	}
+
+	var tags []string
+
+	for _, l := range s.Labels {
+		value := invalidTagChars.ReplaceAllString(l.Value, "_")
+
+		switch c.Tags {
+		case TagsNone:
+			name += "." + invalidNameChars.ReplaceAllString(value, "_")
+		case TagsDogStatsD:
+			tags = append(tags, l.Name+":"+value)
+		case TagsInflux:
+			tags = append(tags, l.Name+"="+value)
+		}
+	}
+
+	name = invalidNameChars.ReplaceAllString(name, "_")
+
+	var suffix string
+
+	switch c.Tags {
+	case TagsDogStatsD:
+		if len(tags) > 0 {
+			suffix = "|#" + strings.Join(tags, ",")
+		}
+	case TagsInflux:
+		if len(tags) > 0 {
+			name += "," + strings.Join(tags, ",")
+		}
+	case TagsNone:
+	}
+
+	prefix := name + ":"
+
+	value := strconv.FormatFloat(s.Value, 'f', -1, 64)
+	if s.Value < 0 {
+		return []string{prefix + "0|g" + suffix, prefix + value + "|g" + suffix}
+	}
+
+	return []string{prefix + value + "|g" + suffix}
+}
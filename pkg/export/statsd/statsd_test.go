@@ -0,0 +1,191 @@
+package statsd
+
+import (
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/schubergphilis/rpi_exporter/pkg/export/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listen returns a client of the given tag style sending to a local UDP socket, and a function
+// returning the datagrams received so far.
+func listen(t *testing.T, tags TagStyle) (*Client, func() []string) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pc.Close() })
+
+	c, err := Dial(pc.LocalAddr().String(), tags)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	received := func() []string {
+		var out []string
+
+		buf := make([]byte, 1<<16)
+
+		for {
+			require.NoError(t, pc.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+
+			n, _, err := pc.ReadFrom(buf)
+			if err != nil {
+				return out
+			}
+
+			out = append(out, string(buf[:n]))
+		}
+	}
+
+	return c, received
+}
+
+func sample(name string, value float64, labels ...string) prometheus.Sample {
+	s := prometheus.Sample{Name: name, Value: value}
+
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.Labels = append(s.Labels, prometheus.Label{Name: labels[i], Value: labels[i+1]})
+	}
+
+	return s
+}
+
+func TestDial(t *testing.T) {
+	_, err := Dial("127.0.0.1:8125", "graphite")
+	require.Error(t, err)
+
+	_, err = Dial("127.0.0.1", TagsNone)
+	require.Error(t, err)
+}
+
+func TestLines(t *testing.T) {
+	samples := []prometheus.Sample{
+		sample("rpi_temperature_celsius", 48.3, "id", "soc"),
+		sample("rpi_clock_rate_hz", 1500000000, "id", "arm", "kind", "set point"),
+		sample("rpi_up", 1),
+		sample("rpi_offset", -2.5, "id", "a:b|c@d#e,f=g h"),
+		sample("rpi bad/name", 0, "id", "x.y/z"),
+	}
+
+	tests := []struct {
+		tags   TagStyle
+		prefix string
+		want   [][]string
+	}{
+		{
+			tags:   TagsNone,
+			prefix: "pi.lab",
+			want: [][]string{
+				{"pi.lab.rpi_temperature_celsius.soc:48.3|g"},
+				{"pi.lab.rpi_clock_rate_hz.arm.set_point:1500000000|g"},
+				{"pi.lab.rpi_up:1|g"},
+				{"pi.lab.rpi_offset.a_b_c_d_e_f_g_h:0|g", "pi.lab.rpi_offset.a_b_c_d_e_f_g_h:-2.5|g"},
+				{"pi.lab.rpi_bad_name.x.y_z:0|g"},
+			},
+		},
+		{
+			tags: TagsDogStatsD,
+			want: [][]string{
+				{"rpi_temperature_celsius:48.3|g|#id:soc"},
+				{"rpi_clock_rate_hz:1500000000|g|#id:arm,kind:set_point"},
+				{"rpi_up:1|g"},
+				{"rpi_offset:0|g|#id:a_b_c_d_e_f_g_h", "rpi_offset:-2.5|g|#id:a_b_c_d_e_f_g_h"},
+				{"rpi_bad_name:0|g|#id:x.y/z"},
+			},
+		},
+		{
+			tags: TagsInflux,
+			want: [][]string{
+				{"rpi_temperature_celsius,id=soc:48.3|g"},
+				{"rpi_clock_rate_hz,id=arm,kind=set_point:1500000000|g"},
+				{"rpi_up:1|g"},
+				{"rpi_offset,id=a_b_c_d_e_f_g_h:0|g", "rpi_offset,id=a_b_c_d_e_f_g_h:-2.5|g"},
+				{"rpi_bad_name,id=x.y/z:0|g"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.tags), func(t *testing.T) {
+			c := &Client{Prefix: tt.prefix, Tags: tt.tags}
+
+			for i, s := range samples {
+				assert.Equal(t, tt.want[i], c.lines(s), s.Name)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	c, received := listen(t, TagsDogStatsD)
+
+	require.NoError(t, c.Send([]prometheus.Sample{
+		sample("a", 1),
+		sample("nan", math.NaN()),
+		sample("inf", math.Inf(1)),
+		sample("neg_inf", math.Inf(-1)),
+		sample("b", -1, "id", "x"),
+	}))
+
+	assert.Equal(t, []string{"a:1|g\nb:0|g|#id:x\nb:-1|g|#id:x"}, received())
+
+	// Nothing is sent if every sample is skipped.
+	require.NoError(t, c.Send([]prometheus.Sample{sample("nan", math.NaN())}))
+	assert.Empty(t, received())
+}
+
+func TestSendPacksDatagrams(t *testing.T) {
+	c, received := listen(t, TagsNone)
+	c.MaxPacketSize = 20
+
+	// "metric_a:1|g" is 12 bytes, so two lines with their separator need 25 bytes.
+	require.NoError(t, c.Send([]prometheus.Sample{
+		sample("metric_a", 1), sample("metric_b", 2), sample("m", 3), sample("n", 4), sample("o", 5),
+	}))
+
+	assert.Equal(t, []string{"metric_a:1|g", "metric_b:2|g\nm:3|g", "n:4|g\no:5|g"}, received())
+}
+
+func TestSendPacksDatagramsAtLimit(t *testing.T) {
+	c, received := listen(t, TagsNone)
+
+	// Each line is 8 bytes, so exactly 4 lines and their separators fill 35 bytes.
+	c.MaxPacketSize = 35
+
+	var samples []prometheus.Sample
+	for range 9 {
+		samples = append(samples, sample("abcd", 1))
+	}
+
+	require.NoError(t, c.Send(samples))
+
+	full := strings.Repeat("abcd:1|g\n", 3) + "abcd:1|g"
+	assert.Equal(t, []string{full, full, "abcd:1|g"}, received())
+}
+
+func TestSendKeepsNegativeValueTogether(t *testing.T) {
+	c, received := listen(t, TagsNone)
+
+	// "a:1|g" fills the first datagram so far that only the reset of "b" would still fit.
+	c.MaxPacketSize = len("a:1|g\nb:0|g")
+
+	require.NoError(t, c.Send([]prometheus.Sample{sample("a", 1), sample("b", -1), sample("c", -2)}))
+
+	// The reset and the value of a sample always share a datagram, even one larger than the limit.
+	assert.Equal(t, []string{"a:1|g", "b:0|g\nb:-1|g", "c:0|g\nc:-2|g"}, received())
+}
+
+func TestSendOversizedLine(t *testing.T) {
+	c, received := listen(t, TagsNone)
+	c.MaxPacketSize = 4
+
+	require.NoError(t, c.Send([]prometheus.Sample{sample("long", 1), sample("x", 2)}))
+
+	// Lines longer than the limit are sent on their own rather than dropped.
+	assert.Equal(t, []string{"long:1|g", "x:2|g"}, received())
+}
@@ -0,0 +1,61 @@
+/*
+Package protowire appends protobuf fields to a message. The exporters only produce a few messages,
+which are encoded by hand to avoid depending on a protobuf runtime. The wire format is documented
+here:
+
+https://protobuf.dev/programming-guides/encoding/
+*/
+package protowire
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+const tagShift = 3
+
+// Buffer is an encoded message. Fields are appended by its methods, which return the extended
+// buffer like append.
+type Buffer []byte
+
+func (b Buffer) tag(field, wire int) Buffer {
+	return binary.AppendUvarint(b, uint64(field<<tagShift|wire))
+}
+
+// Varint appends a varint field.
+func (b Buffer) Varint(field int, v uint64) Buffer {
+	return binary.AppendUvarint(b.tag(field, wireVarint), v)
+}
+
+// Fixed64 appends a fixed64 field.
+func (b Buffer) Fixed64(field int, v uint64) Buffer {
+	return binary.LittleEndian.AppendUint64(b.tag(field, wireFixed64), v)
+}
+
+// Double appends a double field.
+func (b Buffer) Double(field int, v float64) Buffer {
+	return b.Fixed64(field, math.Float64bits(v))
+}
+
+// Bytes appends a length-delimited field, such as an embedded message.
+func (b Buffer) Bytes(field int, v []byte) Buffer {
+	b = binary.AppendUvarint(b.tag(field, wireBytes), uint64(len(v)))
+
+	return append(b, v...)
+}
+
+// String appends a string field, leaving out the empty string.
+func (b Buffer) String(field int, v string) Buffer {
+	if v == "" {
+		return b
+	}
+
+	return b.Bytes(field, []byte(v))
+}
@@ -0,0 +1,32 @@
+package protowire
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  Buffer
+		want []byte
+	}{
+		{"varint", Buffer{}.Varint(1, 150), []byte{0x08, 0x96, 0x01}},
+		{"varint zero", Buffer{}.Varint(3, 0), []byte{0x18, 0x00}},
+		{"large field number", Buffer{}.Varint(16, 1), []byte{0x80, 0x01, 0x01}},
+		{"fixed64", Buffer{}.Fixed64(2, 1), []byte{0x11, 1, 0, 0, 0, 0, 0, 0, 0}},
+		{"double", Buffer{}.Double(1, 1), []byte{0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f}},
+		{"double NaN", Buffer{}.Double(1, math.NaN()), []byte{0x09, 1, 0, 0, 0, 0, 0, 0xf8, 0x7f}},
+		{"string", Buffer{}.String(2, "testing"), []byte{0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g'}},
+		{"empty string", Buffer{}.String(2, ""), []byte{}},
+		{"empty bytes", Buffer{}.Bytes(2, nil), []byte{0x12, 0x00}},
+		{"embedded", Buffer{}.Bytes(3, Buffer{}.Varint(1, 150)), []byte{0x1a, 0x03, 0x08, 0x96, 0x01}},
+		{"chained", Buffer{}.Varint(1, 1).String(2, "a"), []byte{0x08, 0x01, 0x12, 0x01, 'a'}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, []byte(tt.buf), tt.name)
+	}
+}
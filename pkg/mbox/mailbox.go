@@ -6,10 +6,13 @@ https://github.com/raspberrypi/firmware/wiki/Mailbox-property-interface
 package mbox
 
 import (
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math"
 	"os"
+	"time"
 	"unsafe"
 
 	"github.com/schubergphilis/rpi_exporter/pkg/ioctl"
@@ -39,13 +42,21 @@ const (
 	MailboxMilliScale         = 1000
 	MailboxMicroScale         = 1000000
 	MailboxTwoWords           = 2
+	FirmwareHashBytes         = 20
+	// MailboxMaxValueBytes is the largest tag value buffer that fits in the aligned request buffer
+	// alongside the request header and end tag.
+	MailboxMaxValueBytes = (MailboxDefaultBufferWords - MailboxBufferAlignment/MailboxWordBytes -
+		MailboxRequestHeaderWords - MailboxEndTagWords) * MailboxWordBytes
 )
 
 const (
 	TagGetFirmwareRevision  = 0x00000001
+	TagGetFirmwareVariant   = 0x00000002
+	TagGetFirmwareHash      = 0x00000003
 	TagGetBoardModel        = 0x00010001
 	TagGetBoardRevision     = 0x00010002
 	TagGetBoardMAC          = 0x00010003
+	TagGetBoardSerial       = 0x00010004
 	TagGetPowerState        = 0x00020001
 	TagGetClockRate         = 0x00030002
 	TagGetVoltage           = 0x00030003
@@ -54,6 +65,7 @@ const (
 	TagGetMinVoltage        = 0x00030008
 	TagGetTurbo             = 0x00030009
 	TagGetMaxTemperature    = 0x0003000A
+	TagGetThrottled         = 0x00030046
 	TagGetClockRateMeasured = 0x00030047
 )
 
@@ -109,13 +121,28 @@ func (t Tag) IsResponse() bool {
 	return t[2]&MailboxResponseSuccessBit == MailboxResponseSuccessBit
 }
 
-// Value returns the value buffer. TODO: Always 32bit.
+// Value returns the value buffer. If the response is longer than the value buffer, the value is
+// truncated to the buffer capacity. TODO: Always 32bit.
 func (t Tag) Value() []uint32 {
 	if !t.IsValid() {
 		return nil
 	}
 
-	return t[3 : 3+t.Len()/MailboxWordBytes]
+	n := min(t.Len(), t.Cap()) / MailboxWordBytes
+
+	return t[MailboxMinCompleteTagLen : MailboxMinCompleteTagLen+n]
+}
+
+// Bytes returns the value buffer as little-endian bytes, limited to the response length.
+func (t Tag) Bytes() []byte {
+	value := t.Value()
+	b := make([]byte, 0, len(value)*MailboxWordBytes)
+
+	for _, v := range value {
+		b = binary.LittleEndian.AppendUint32(b, v)
+	}
+
+	return b[:min(len(b), t.Len())]
 }
 
 func (t Tag) IsEnd() bool {
@@ -223,6 +250,53 @@ func (m *Mailbox) GetFirmwareRevision() (uint32, error) {
 	return m.getUint32(TagGetFirmwareRevision)
 }
 
+// FirmwareVariant identifies the firmware image (start*.elf) the VideoCore booted.
+type FirmwareVariant uint32
+
+const (
+	FirmwareVariantUnknown FirmwareVariant = 0x00000000
+	FirmwareVariantStart   FirmwareVariant = 0x00000001
+	FirmwareVariantStartX  FirmwareVariant = 0x00000002
+	FirmwareVariantStartDB FirmwareVariant = 0x00000003
+	FirmwareVariantStartCD FirmwareVariant = 0x00000004
+)
+
+func (v FirmwareVariant) String() string {
+	switch v {
+	case FirmwareVariantStart:
+		return "start"
+	case FirmwareVariantStartX:
+		return "start_x"
+	case FirmwareVariantStartDB:
+		return "start_db"
+	case FirmwareVariantStartCD:
+		return "start_cd"
+	case FirmwareVariantUnknown:
+	}
+
+	return "unknown"
+}
+
+// GetFirmwareVariant returns the variant of the running VideoCore firmware.
+func (m *Mailbox) GetFirmwareVariant() (FirmwareVariant, error) {
+	v, err := m.getUint32(TagGetFirmwareVariant)
+	if err != nil {
+		return 0, err
+	}
+
+	return FirmwareVariant(v), nil
+}
+
+// GetFirmwareHash returns the git hash of the running VideoCore firmware as a hex string.
+func (m *Mailbox) GetFirmwareHash() (string, error) {
+	tags, err := m.Do(TagGetFirmwareHash, FirmwareHashBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(tags[0].Bytes()), nil
+}
+
 // GetBoardModel returns the model number of the system board.
 func (m *Mailbox) GetBoardModel() (uint32, error) {
 	return m.getUint32(TagGetBoardModel)
@@ -233,6 +307,21 @@ func (m *Mailbox) GetBoardRevision() (uint32, error) {
 	return m.getUint32(TagGetBoardRevision)
 }
 
+// GetBoardSerial returns the serial number of the system board.
+func (m *Mailbox) GetBoardSerial() (uint64, error) {
+	tags, err := m.Do(TagGetBoardSerial, MailboxTwoWords*MailboxWordBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	v := tags[0].Value()
+	if len(v) < MailboxTwoWords {
+		return 0, fmt.Errorf("short board serial response of %d words", len(v))
+	}
+
+	return uint64(v[1])<<32 | uint64(v[0]), nil
+}
+
 // PowerDeviceID identifiers.
 type PowerDeviceID uint32
 
@@ -355,11 +444,46 @@ func (m *Mailbox) GetTurbo() (bool, error) {
 	return tags[0].Value()[PowerStateReturnIdx] == 1, nil
 }
 
-// alignBuffer ensures the buffer is aligned to a 16-byte boundary.
+// Throttled flags as returned by GetThrottled. The low bits report the current state, the high bits
+// are sticky and report whether the condition occurred since boot.
+const (
+	ThrottledUnderVoltage          uint32 = 1 << 0
+	ThrottledFreqCapped            uint32 = 1 << 1
+	ThrottledThrottled             uint32 = 1 << 2
+	ThrottledSoftTempLimit         uint32 = 1 << 3
+	ThrottledUnderVoltageOccurred  uint32 = 1 << 16
+	ThrottledFreqCappedOccurred    uint32 = 1 << 17
+	ThrottledThrottledOccurred     uint32 = 1 << 18
+	ThrottledSoftTempLimitOccurred uint32 = 1 << 19
+)
+
+// ThrottledClearAll requests the firmware to clear all sticky throttled flags.
+const ThrottledClearAll uint32 = 0xffff
+
+// GetThrottled returns the throttled state bitmask of the SoC, as reported by vcgencmd get_throttled.
+func (m *Mailbox) GetThrottled() (uint32, error) {
+	return m.GetThrottledAndClear(0)
+}
+
+// GetThrottledAndClear returns the throttled state bitmask of the SoC and requests the firmware to
+// clear the sticky flags selected by mask, so that they report whether the condition occurred since
+// this call rather than since boot. This affects all other readers of the flags.
+func (m *Mailbox) GetThrottledAndClear(mask uint32) (uint32, error) {
+	tags, err := m.Do(TagGetThrottled, MailboxWordBytes, mask)
+	if err != nil {
+		return 0, err
+	}
+
+	return tags[0].Value()[GetUint32ReturnIdx], nil
+}
+
+// alignBuffer ensures the buffer is aligned to a 16-byte boundary. The buffer is always
+// MailboxBufferAlignment bytes shorter than the unaligned one, whatever its offset.
 func (m *Mailbox) alignBuffer() {
 	if m.buf == nil {
 		offset := uintptr(unsafe.Pointer(&m.bufUnaligned[0])) & (MailboxBufferAlignment - 1)
-		m.buf = m.bufUnaligned[MailboxBufferAlignment-offset : uintptr(len(m.bufUnaligned))-offset]
+		skip := (MailboxBufferAlignment - offset) % MailboxBufferAlignment / MailboxWordBytes
+		m.buf = m.bufUnaligned[skip : skip+MailboxDefaultBufferWords-MailboxBufferAlignment/MailboxWordBytes]
 	}
 }
 
@@ -382,16 +506,20 @@ func (m *Mailbox) writeRequestHeader(bufferBytes int, tagID uint32, args []uint3
 		return fmt.Errorf("mailbox header length out of range: %d", computedLen)
 	}
 
-	if bufferBytes < 0 || bufferBytes > int(math.MaxUint32) {
+	if bufferBytes < 0 || bufferBytes > MailboxMaxValueBytes {
 		return fmt.Errorf("mailbox bufferBytes out of range: %d", bufferBytes)
 	}
 
+	valueWords := (bufferBytes + MailboxWordBytes - 1) / MailboxWordBytes
+
 	m.buf[0] = uint32(computedLen)
 	m.buf[1] = RequestCodeDefault
 	m.buf[2] = tagID
 	m.buf[3] = uint32(bufferBytes)
 	m.buf[4] = 0 // request
+	clear(m.buf[MailboxRequestHeaderWords : MailboxRequestHeaderWords+valueWords])
 	copy(m.buf[MailboxRequestHeaderWords:], args)
+	m.buf[MailboxRequestHeaderWords+valueWords] = MailboxEndTagValue
 
 	return nil
 }
@@ -405,7 +533,12 @@ func (m *Mailbox) debugBuffer(format string, buf []uint32) {
 
 // sendIOCTL sends the buffer via ioctl.
 func (m *Mailbox) sendIOCTL() error {
-	if err := ioctl.Ioctl(m.f.Fd(), uintptr(mbIoctl), uintptr(unsafe.Pointer(&m.buf[0]))); err != nil {
+	start := time.Now()
+	err := ioctl.Ioctl(m.f.Fd(), uintptr(mbIoctl), uintptr(unsafe.Pointer(&m.buf[0])))
+
+	observeIoctl(time.Since(start), err)
+
+	if err != nil {
 		return fmt.Errorf("failed to send via ioctl: %w", err)
 	}
 
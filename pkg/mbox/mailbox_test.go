@@ -0,0 +1,33 @@
+package mbox
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignBuffer(t *testing.T) {
+	m := &Mailbox{}
+	m.alignBuffer()
+
+	assert.Zero(t, uintptr(unsafe.Pointer(&m.buf[0]))%MailboxBufferAlignment)
+	assert.Len(t, m.buf, MailboxDefaultBufferWords-MailboxBufferAlignment/MailboxWordBytes)
+}
+
+func TestWriteRequestHeaderMaxValueBytes(t *testing.T) {
+	m := &Mailbox{}
+	m.alignBuffer()
+
+	for size := 0; size <= MailboxMaxValueBytes; size += MailboxWordBytes {
+		require.NoError(t, m.writeRequestHeader(size, TagGetBoardRevision, nil), "size %d", size)
+	}
+
+	valueWords := MailboxMaxValueBytes / MailboxWordBytes
+	assert.Equal(t, uint32(MailboxEndTagValue), m.buf[MailboxRequestHeaderWords+valueWords])
+	assert.Len(t, m.buf, MailboxRequestHeaderWords+valueWords+MailboxEndTagWords)
+
+	require.Error(t, m.writeRequestHeader(MailboxMaxValueBytes+MailboxWordBytes, TagGetBoardRevision, nil))
+	require.Error(t, m.writeRequestHeader(-1, TagGetBoardRevision, nil))
+}
@@ -0,0 +1,56 @@
+package mbox
+
+import (
+	"sync"
+	"time"
+)
+
+// IoctlLatencyBuckets are the upper bounds in seconds of the ioctl latency histogram.
+var IoctlLatencyBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1}
+
+// IoctlStats holds cumulative statistics of the ioctl calls made to the VideoCore since start.
+type IoctlStats struct {
+	Since  time.Time `json:"since"`
+	Count  uint64    `json:"count"`
+	Errors uint64    `json:"errors"`
+	// Sum is the total time spent in ioctl calls in seconds.
+	Sum float64 `json:"sum_seconds"`
+	// Buckets holds the cumulative count of calls per upper bound in IoctlLatencyBuckets.
+	Buckets []uint64 `json:"buckets"`
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = IoctlStats{Since: time.Now(), Buckets: make([]uint64, len(IoctlLatencyBuckets))}
+)
+
+// Stats returns a copy of the ioctl statistics.
+func Stats() IoctlStats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	s := stats
+	s.Buckets = append([]uint64{}, stats.Buckets...)
+
+	return s
+}
+
+func observeIoctl(d time.Duration, err error) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	seconds := d.Seconds()
+
+	stats.Count++
+	stats.Sum += seconds
+
+	if err != nil {
+		stats.Errors++
+	}
+
+	for i, upper := range IoctlLatencyBuckets {
+		if seconds <= upper {
+			stats.Buckets[i]++
+		}
+	}
+}
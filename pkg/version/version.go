@@ -0,0 +1,41 @@
+/*
+Package version holds the build information of rpi_exporter. Version and Commit are injected at
+build time, e.g.:
+
+	go build -ldflags "-X github.com/schubergphilis/rpi_exporter/pkg/version.Version=v1.0.0"
+*/
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information injected with -ldflags -X.
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information. If no commit was injected, the VCS revision embedded by the Go
+// toolchain is used.
+func Get() Info {
+	commit := Commit
+
+	if info, ok := debug.ReadBuildInfo(); ok && commit == "" {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				commit = setting.Value
+			}
+		}
+	}
+
+	return Info{Version: Version, Commit: commit, GoVersion: runtime.Version()}
+}
@@ -0,0 +1,240 @@
+/*
+Package web secures the HTTP listener of the exporter with TLS, client certificate verification and
+basic authentication, configured by a web config file in the format of the Prometheus
+exporter-toolkit:
+
+https://github.com/prometheus/exporter-toolkit/blob/master/docs/web-configuration.md
+*/
+package web
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a web config file.
+type Config struct {
+	TLS   TLSConfig         `yaml:"tls_server_config"`
+	HTTP  HTTPConfig        `yaml:"http_server_config"`
+	Users map[string]string `yaml:"basic_auth_users"`
+}
+
+// TLSConfig configures TLS. TLS is disabled if neither CertFile nor KeyFile is set.
+type TLSConfig struct {
+	CertFile          string        `yaml:"cert_file"`
+	KeyFile           string        `yaml:"key_file"`
+	ClientAuth        ClientAuth    `yaml:"client_auth_type"`
+	ClientCAFile      string        `yaml:"client_ca_file"`
+	ClientAllowedSANs []string      `yaml:"client_allowed_sans"`
+	MinVersion        TLSVersion    `yaml:"min_version"`
+	MaxVersion        TLSVersion    `yaml:"max_version"`
+	CipherSuites      []CipherSuite `yaml:"cipher_suites"`
+	CurvePreferences  []Curve       `yaml:"curve_preferences"`
+	// PreferServerCipherSuites is accepted for compatibility and ignored, as Go orders cipher
+	// suites itself.
+	PreferServerCipherSuites bool `yaml:"prefer_server_cipher_suites"`
+}
+
+// HTTPConfig configures HTTP.
+type HTTPConfig struct {
+	// HTTP2 enables HTTP/2 over TLS, the default.
+	HTTP2 *bool `yaml:"http2"`
+	// Headers are added to every response.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// allowedHeaders are the response headers that may be configured.
+var allowedHeaders = []string{
+	"Content-Security-Policy",
+	"Strict-Transport-Security",
+	"X-Content-Type-Options",
+	"X-Frame-Options",
+	"X-XSS-Protection",
+}
+
+// LoadConfig reads and validates a web config file. Relative paths in the file are resolved
+// against its directory.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read web config: %w", err)
+	}
+
+	cfg := &Config{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unable to parse web config %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&cfg.TLS.CertFile, &cfg.TLS.KeyFile, &cfg.TLS.ClientCAFile} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid web config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	for user, hash := range c.Users {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return fmt.Errorf("password of user %q: %w", user, err)
+		}
+	}
+
+	for name := range c.HTTP.Headers {
+		if !slices.ContainsFunc(allowedHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+			return fmt.Errorf("header %q can not be configured", name)
+		}
+	}
+
+	return c.TLS.validate()
+}
+
+// Enabled reports whether TLS is enabled.
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// files returns the files the configuration refers to.
+func (c *TLSConfig) files() []string {
+	var files []string
+
+	for _, f := range []string{c.CertFile, c.KeyFile, c.ClientCAFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+func (c *TLSConfig) validate() error {
+	switch {
+	case !c.Enabled():
+		if c.ClientCAFile != "" || c.ClientAuth != "" || len(c.ClientAllowedSANs) > 0 {
+			return errors.New("tls_server_config requires cert_file and key_file")
+		}
+
+		return nil
+	case c.CertFile == "":
+		return errors.New("tls_server_config is missing cert_file")
+	case c.KeyFile == "":
+		return errors.New("tls_server_config is missing key_file")
+	}
+
+	auth, err := c.ClientAuth.authType()
+	if err != nil {
+		return err
+	}
+
+	verifies := auth == tls.VerifyClientCertIfGiven || auth == tls.RequireAndVerifyClientCert
+
+	switch {
+	case c.ClientCAFile != "" && !verifies:
+		return fmt.Errorf("client_ca_file requires a verifying client_auth_type, got %q", c.ClientAuth)
+	case c.ClientCAFile == "" && verifies:
+		return fmt.Errorf("client_auth_type %q requires client_ca_file", c.ClientAuth)
+	case len(c.ClientAllowedSANs) > 0 && !verifies:
+		return errors.New("client_allowed_sans requires a verifying client_auth_type")
+	case c.MaxVersion != 0 && c.MinVersion > c.MaxVersion:
+		return errors.New("min_version must not be greater than max_version")
+	}
+
+	return nil
+}
+
+// build loads the certificates and returns the tls.Config described by c.
+func (c *TLSConfig) build() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load server certificate: %w", err)
+	}
+
+	auth, err := c.ClientAuth.authType()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   auth,
+		MinVersion:   tls.VersionTLS12,
+		MaxVersion:   uint16(c.MaxVersion),
+	}
+
+	if c.MinVersion != 0 {
+		cfg.MinVersion = uint16(c.MinVersion)
+	}
+
+	for _, suite := range c.CipherSuites {
+		cfg.CipherSuites = append(cfg.CipherSuites, uint16(suite))
+	}
+
+	for _, curve := range c.CurvePreferences {
+		cfg.CurvePreferences = append(cfg.CurvePreferences, tls.CurveID(curve))
+	}
+
+	if c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client CA: %w", err)
+		}
+
+		cfg.ClientCAs = x509.NewCertPool()
+		if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA %s", c.ClientCAFile)
+		}
+	}
+
+	if len(c.ClientAllowedSANs) > 0 {
+		cfg.VerifyPeerCertificate = c.verifySANs
+	}
+
+	return cfg, nil
+}
+
+// verifySANs accepts client certificates with a subject alternative name in ClientAllowedSANs.
+func (c *TLSConfig) verifySANs(_ [][]byte, chains [][]*x509.Certificate) error {
+	if len(chains) == 0 {
+		return nil
+	}
+
+	cert := chains[0][0]
+
+	sans := slices.Concat(cert.DNSNames, cert.EmailAddresses)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	for _, san := range sans {
+		if slices.Contains(c.ClientAllowedSANs, san) {
+			return nil
+		}
+	}
+
+	return errors.New("client certificate has no allowed subject alternative name")
+}
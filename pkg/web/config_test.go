@@ -0,0 +1,214 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHash = "$2b$04$abcdefghijklmnopqrstuuLZYjhNQAOdpbzt4WxWlUHjv1wsyH5DG"
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "web.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+tls_server_config:
+  cert_file: server.crt
+  key_file: /etc/rpi_exporter/server.key
+  client_auth_type: RequireAndVerifyClientCert
+  client_ca_file: ca.crt
+  client_allowed_sans: [client.example.com]
+  min_version: TLS12
+  max_version: TLS13
+  cipher_suites: [TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256]
+  curve_preferences: [X25519, CurveP256]
+http_server_config:
+  http2: false
+  headers:
+    x-frame-options: deny
+basic_auth_users:
+  prometheus: "`+testHash+`"
+`)
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	dir := filepath.Dir(path)
+	assert.Equal(t, filepath.Join(dir, "server.crt"), cfg.TLS.CertFile)
+	assert.Equal(t, "/etc/rpi_exporter/server.key", cfg.TLS.KeyFile)
+	assert.Equal(t, filepath.Join(dir, "ca.crt"), cfg.TLS.ClientCAFile)
+	assert.Equal(t, ClientAuth("RequireAndVerifyClientCert"), cfg.TLS.ClientAuth)
+	assert.Equal(t, []string{"client.example.com"}, cfg.TLS.ClientAllowedSANs)
+	assert.Equal(t, TLSVersion(tls.VersionTLS12), cfg.TLS.MinVersion)
+	assert.Equal(t, TLSVersion(tls.VersionTLS13), cfg.TLS.MaxVersion)
+	assert.Equal(t, []CipherSuite{CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256)}, cfg.TLS.CipherSuites)
+	assert.Equal(t, []Curve{Curve(tls.X25519), Curve(tls.CurveP256)}, cfg.TLS.CurvePreferences)
+	require.NotNil(t, cfg.HTTP.HTTP2)
+	assert.False(t, *cfg.HTTP.HTTP2)
+	assert.Equal(t, map[string]string{"x-frame-options": "deny"}, cfg.HTTP.Headers)
+	assert.Equal(t, map[string]string{"prometheus": testHash}, cfg.Users)
+}
+
+func TestLoadConfigEmpty(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, ""))
+	require.NoError(t, err)
+	assert.False(t, cfg.TLS.Enabled())
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":        "tls_server_config:\n  certfile: server.crt\n",
+		"unknown tls version":  "tls_server_config:\n  min_version: SSL30\n",
+		"unknown cipher suite": "tls_server_config:\n  cipher_suites: [TLS_RSA_WITH_RC4_128_SHA]\n",
+		"unknown curve":        "tls_server_config:\n  curve_preferences: [CurveP224]\n",
+		"invalid hash":         "basic_auth_users:\n  prometheus: secret\n",
+		"header not allowed":   "http_server_config:\n  headers:\n    Server: rpi\n",
+		"min above max": "tls_server_config:\n  cert_file: a\n  key_file: b\n" +
+			"  min_version: TLS13\n  max_version: TLS12\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, content))
+			require.Error(t, err)
+		})
+	}
+
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yml"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestConfigValidateHeaders(t *testing.T) {
+	for _, name := range allowedHeaders {
+		cfg := &Config{HTTP: HTTPConfig{Headers: map[string]string{name: "x"}}}
+		require.NoError(t, cfg.validate(), name)
+	}
+
+	cfg := &Config{HTTP: HTTPConfig{Headers: map[string]string{"strict-transport-security": "max-age=31536000"}}}
+	require.NoError(t, cfg.validate())
+
+	for _, name := range []string{"Server", "Content-Type", "WWW-Authenticate", "Set-Cookie"} {
+		cfg := &Config{HTTP: HTTPConfig{Headers: map[string]string{name: "x"}}}
+		require.Error(t, cfg.validate(), name)
+	}
+}
+
+func TestConfigValidateUsers(t *testing.T) {
+	valid := []string{
+		"$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW",
+		testHash,
+		"$2y$06$012345678901234567890uqVZr.MH/ilDUeb21bUdUlpD8DaVGGMO",
+		"$2b$31$abcdefghijklmnopqrstuuLZYjhNQAOdpbzt4WxWlUHjv1wsyH5DG",
+	}
+
+	for _, hash := range valid {
+		cfg := &Config{Users: map[string]string{"prometheus": hash}}
+		require.NoError(t, cfg.validate(), hash)
+	}
+
+	invalid := []string{
+		"",
+		"s3cret",
+		"$2b$04$abcdefghijklmnopqrstuu",
+		"$3b$04$abcdefghijklmnopqrstuuLZYjhNQAOdpbzt4WxWlUHjv1wsyH5DG",
+		"$2b$03$abcdefghijklmnopqrstuuLZYjhNQAOdpbzt4WxWlUHjv1wsyH5DG",
+		"$2b$32$abcdefghijklmnopqrstuuLZYjhNQAOdpbzt4WxWlUHjv1wsyH5DG",
+		"$2b$1a$abcdefghijklmnopqrstuuLZYjhNQAOdpbzt4WxWlUHjv1wsyH5DG",
+	}
+
+	for _, hash := range invalid {
+		cfg := &Config{Users: map[string]string{"prometheus": hash}}
+		require.Error(t, cfg.validate(), hash)
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	enabled := func(c TLSConfig) TLSConfig {
+		c.CertFile, c.KeyFile = "server.crt", "server.key"
+
+		return c
+	}
+
+	valid := map[string]TLSConfig{
+		"disabled":        {},
+		"enabled":         enabled(TLSConfig{}),
+		"no client cert":  enabled(TLSConfig{ClientAuth: "NoClientCert"}),
+		"request":         enabled(TLSConfig{ClientAuth: "RequestClientCert"}),
+		"require any":     enabled(TLSConfig{ClientAuth: "RequireAnyClientCert"}),
+		"verify if given": enabled(TLSConfig{ClientAuth: "VerifyClientCertIfGiven", ClientCAFile: "ca.crt"}),
+		"require and verify with sans": enabled(TLSConfig{
+			ClientAuth: "RequireAndVerifyClientCert", ClientCAFile: "ca.crt", ClientAllowedSANs: []string{"a"},
+		}),
+		"equal versions": enabled(TLSConfig{MinVersion: tls.VersionTLS13, MaxVersion: tls.VersionTLS13}),
+		"min only":       enabled(TLSConfig{MinVersion: tls.VersionTLS13}),
+		"max only":       enabled(TLSConfig{MaxVersion: tls.VersionTLS12}),
+	}
+
+	for name, c := range valid {
+		require.NoError(t, c.validate(), name)
+	}
+
+	invalid := map[string]TLSConfig{
+		"ca without tls":          {ClientCAFile: "ca.crt"},
+		"client auth without tls": {ClientAuth: "RequireAnyClientCert"},
+		"sans without tls":        {ClientAllowedSANs: []string{"a"}},
+		"missing cert":            {KeyFile: "server.key"},
+		"missing key":             {CertFile: "server.crt"},
+		"unknown client auth":     enabled(TLSConfig{ClientAuth: "RequireClientCert"}),
+		"ca without verifying":    enabled(TLSConfig{ClientAuth: "RequireAnyClientCert", ClientCAFile: "ca.crt"}),
+		"ca without client auth":  enabled(TLSConfig{ClientCAFile: "ca.crt"}),
+		"verifying without ca":    enabled(TLSConfig{ClientAuth: "RequireAndVerifyClientCert"}),
+		"sans without verifying": enabled(TLSConfig{
+			ClientAuth: "RequireAnyClientCert", ClientAllowedSANs: []string{"a"},
+		}),
+		"min above max": enabled(TLSConfig{MinVersion: tls.VersionTLS13, MaxVersion: tls.VersionTLS12}),
+	}
+
+	for name, c := range invalid {
+		require.Error(t, c.validate(), name)
+	}
+}
+
+func TestVerifySANs(t *testing.T) {
+	uri, err := url.Parse("spiffe://example.com/prometheus")
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{
+		DNSNames:       []string{"prometheus.example.com"},
+		EmailAddresses: []string{"ops@example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")},
+		URIs:           []*url.URL{uri},
+	}
+	intermediate := &x509.Certificate{DNSNames: []string{"ca.example.com"}}
+	chains := [][]*x509.Certificate{{cert, intermediate}}
+
+	for _, san := range []string{
+		"prometheus.example.com", "ops@example.com", "192.0.2.1", "2001:db8::1", "spiffe://example.com/prometheus",
+	} {
+		c := &TLSConfig{ClientAllowedSANs: []string{"other.example.com", san}}
+		require.NoError(t, c.verifySANs(nil, chains), san)
+	}
+
+	for _, san := range []string{"ca.example.com", "PROMETHEUS.example.com", "192.0.2.2", "example.com"} {
+		c := &TLSConfig{ClientAllowedSANs: []string{san}}
+		require.Error(t, c.verifySANs(nil, chains), san)
+	}
+
+	// Without verified chains, as when no client certificate was given, there is nothing to check.
+	c := &TLSConfig{ClientAllowedSANs: []string{"prometheus.example.com"}}
+	require.NoError(t, c.verifySANs(nil, nil))
+}
@@ -0,0 +1,249 @@
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxCachedLogins bounds the cache of successful logins, which spares clients a bcrypt check on
+// every request.
+const maxCachedLogins = 64
+
+// Server applies a web config file to an HTTP server. The file and the certificates it refers to
+// are reloaded by Watch when they change.
+type Server struct {
+	path  string
+	state atomic.Pointer[serverState]
+
+	mu     sync.Mutex
+	logins map[[sha256.Size]byte]struct{}
+}
+
+// serverState is a loaded web config.
+type serverState struct {
+	config *Config
+	tls    *tls.Config
+	stamp  string
+}
+
+// NewServer loads the web config file at path. Without a path, TLS and authentication are
+// disabled.
+func NewServer(path string) (*Server, error) {
+	s := &Server{path: path, logins: make(map[[sha256.Size]byte]struct{})}
+
+	if path == "" {
+		s.state.Store(&serverState{config: &Config{}})
+
+		return s, nil
+	}
+
+	state, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.state.Store(state)
+
+	return s, nil
+}
+
+func (s *Server) load() (*serverState, error) {
+	cfg, err := LoadConfig(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &serverState{config: cfg, stamp: stamp(s.path, cfg.TLS.files())}
+
+	if !cfg.TLS.Enabled() {
+		return state, nil
+	}
+
+	if state.tls, err = cfg.TLS.build(); err != nil {
+		return nil, err
+	}
+
+	state.tls.NextProtos = []string{"http/1.1"}
+	if cfg.HTTP.HTTP2 == nil || *cfg.HTTP.HTTP2 {
+		state.tls.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	return state, nil
+}
+
+// stamp identifies the versions of the config file and the files it refers to.
+func stamp(path string, files []string) string {
+	var b strings.Builder
+
+	for _, f := range append([]string{path}, files...) {
+		if fi, err := os.Stat(f); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", f, fi.ModTime().UnixNano(), fi.Size())
+		} else {
+			fmt.Fprintf(&b, "%s:-;", f)
+		}
+	}
+
+	return b.String()
+}
+
+// Reload reloads the web config file. The current config is kept if the file is invalid. TLS can
+// not be enabled or disabled without a restart.
+func (s *Server) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	state, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if (state.tls == nil) != (s.state.Load().tls == nil) {
+		return errors.New("enabling or disabling TLS requires a restart")
+	}
+
+	s.state.Store(state)
+
+	return nil
+}
+
+// Watch reloads the web config file every interval in which it or the files it refers to changed,
+// until ctx is done.
+func (s *Server) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := s.state.Load().stamp
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cfg := s.state.Load().config
+
+		current := stamp(s.path, cfg.TLS.files())
+		if current == seen {
+			continue
+		}
+
+		seen = current
+
+		if err := s.Reload(); err != nil {
+			log.WithError(err).Error("unable to reload web config")
+
+			continue
+		}
+
+		log.Printf("Reloaded web config %s", s.path)
+	}
+}
+
+// Handler returns a handler that adds the configured headers and requires basic authentication if
+// users are configured, before passing requests to next.
+func (s *Server) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.state.Load().config
+
+		for name, value := range cfg.HTTP.Headers {
+			w.Header().Set(name, value)
+		}
+
+		if len(cfg.Users) > 0 && !s.authorized(cfg.Users, r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="rpi_exporter"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(users map[string]string, r *http.Request) bool {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	// Unknown users are checked against the hash of another user, so that they take as long to reject
+	// as known users.
+	hash, known := users[user]
+	if !known {
+		hash = users[slices.Min(slices.Collect(maps.Keys(users)))]
+	}
+
+	key := sha256.Sum256([]byte(user + "\x00" + hash + "\x00" + password))
+
+	s.mu.Lock()
+	_, cached := s.logins[key]
+	s.mu.Unlock()
+
+	if cached && known {
+		return true
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil || !known {
+		return false
+	}
+
+	s.mu.Lock()
+	if len(s.logins) >= maxCachedLogins {
+		clear(s.logins)
+	}
+
+	s.logins[key] = struct{}{}
+	s.mu.Unlock()
+
+	return true
+}
+
+// Serve serves srv on l, with TLS if configured. The TLS config of each connection is that of the
+// config loaded last.
+func (s *Server) Serve(srv *http.Server, l net.Listener) error {
+	if s.state.Load().tls != nil {
+		l = tls.NewListener(l, &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+				return s.state.Load().tls, nil
+			},
+		})
+	}
+
+	if err := srv.Serve(l); err != nil {
+		return fmt.Errorf("unable to serve http: %w", err)
+	}
+
+	return nil
+}
+
+// ListenAndServe listens on srv.Addr and serves srv, with TLS if configured.
+func (s *Server) ListenAndServe(srv *http.Server) error {
+	l, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s: %w", srv.Addr, err)
+	}
+
+	return s.Serve(srv, l)
+}
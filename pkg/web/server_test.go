@@ -0,0 +1,235 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("ok"))
+})
+
+func serve(s *Server, user, password string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if user != "" {
+		r.SetBasicAuth(user, password)
+	}
+
+	w := httptest.NewRecorder()
+	s.Handler(okHandler).ServeHTTP(w, r)
+
+	return w
+}
+
+func TestHandlerWithoutConfig(t *testing.T) {
+	s, err := NewServer("")
+	require.NoError(t, err)
+
+	w := serve(s, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+	require.NoError(t, s.Reload())
+}
+
+func TestHandlerAuth(t *testing.T) {
+	s, err := NewServer(writeConfig(t, `
+http_server_config:
+  headers:
+    X-Frame-Options: deny
+basic_auth_users:
+  prometheus: "`+testHash+`"
+  legacy: "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW"
+  long: "$2y$06$012345678901234567890uqVZr.MH/ilDUeb21bUdUlpD8DaVGGMO"
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name, user, password string
+		code                 int
+	}{
+		{"no credentials", "", "", http.StatusUnauthorized},
+		{"wrong password", "prometheus", "secret", http.StatusUnauthorized},
+		{"unknown user", "grafana", "s3cret", http.StatusUnauthorized},
+		{"valid", "prometheus", "s3cret", http.StatusOK},
+		{"valid again", "prometheus", "s3cret", http.StatusOK},
+		{"wrong password after valid", "prometheus", "s3cret ", http.StatusUnauthorized},
+		{"password of another user", "grafana", "U*U", http.StatusUnauthorized},
+		{"2a", "legacy", "U*U", http.StatusOK},
+		// Passwords are truncated to 72 bytes.
+		{"2y", "long", strings.Repeat("a", 80), http.StatusOK},
+		{"2y truncated", "long", strings.Repeat("a", 72), http.StatusOK},
+		{"2y too short", "long", strings.Repeat("a", 71), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		w := serve(s, tt.user, tt.password)
+		assert.Equal(t, tt.code, w.Code, tt.name)
+		assert.Equal(t, "deny", w.Header().Get("X-Frame-Options"), tt.name)
+
+		if tt.code == http.StatusUnauthorized {
+			assert.Equal(t, `Basic realm="rpi_exporter"`, w.Header().Get("WWW-Authenticate"), tt.name)
+			assert.NotEqual(t, "ok", w.Body.String(), tt.name)
+		}
+	}
+
+	// Only successful logins are cached.
+	assert.Len(t, s.logins, 4)
+}
+
+func TestHandlerLoginCacheBounded(t *testing.T) {
+	config := "basic_auth_users:\n"
+	for i := range maxCachedLogins + 1 {
+		config += fmt.Sprintf("  user%d: %q\n", i, testHash)
+	}
+
+	s, err := NewServer(writeConfig(t, config))
+	require.NoError(t, err)
+
+	for i := range maxCachedLogins + 1 {
+		require.Equal(t, http.StatusOK, serve(s, fmt.Sprintf("user%d", i), "s3cret").Code)
+		assert.LessOrEqual(t, len(s.logins), maxCachedLogins)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := writeConfig(t, "basic_auth_users:\n  prometheus: \""+testHash+"\"\n")
+
+	s, err := NewServer(path)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, serve(s, "", "").Code)
+
+	// An invalid file keeps the current config.
+	require.NoError(t, os.WriteFile(path, []byte("basic_auth_users:\n  prometheus: secret\n"), 0o600))
+	require.Error(t, s.Reload())
+	require.Equal(t, http.StatusOK, serve(s, "prometheus", "s3cret").Code)
+
+	// A cached login of a removed user is not accepted.
+	require.NoError(t, os.WriteFile(path, []byte("basic_auth_users:\n  grafana: \""+testHash+"\"\n"), 0o600))
+	require.NoError(t, s.Reload())
+	require.Equal(t, http.StatusUnauthorized, serve(s, "prometheus", "s3cret").Code)
+	require.Equal(t, http.StatusOK, serve(s, "grafana", "s3cret").Code)
+
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	require.NoError(t, s.Reload())
+	require.Equal(t, http.StatusOK, serve(s, "", "").Code)
+}
+
+// issue creates a certificate for dnsName, signed by parent or self-signed if parent is nil, and
+// writes it and its key to dir.
+func issue(t *testing.T, dir, name, dnsName string, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{dnsName},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	signer, signerKey := tmpl, any(key)
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0o600))
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func TestServeClientAllowedSANs(t *testing.T) {
+	dir := t.TempDir()
+
+	ca := issue(t, dir, "ca", "ca.example.com", nil)
+	issue(t, dir, "server", "localhost", &ca)
+	allowed := issue(t, dir, "allowed", "prometheus.example.com", &ca)
+	denied := issue(t, dir, "denied", "grafana.example.com", &ca)
+
+	path := filepath.Join(dir, "web.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+tls_server_config:
+  cert_file: server.crt
+  key_file: server.key
+  client_auth_type: RequireAndVerifyClientCert
+  client_ca_file: ca.crt
+  client_allowed_sans: [prometheus.example.com]
+`), 0o600))
+
+	s, err := NewServer(path)
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: s.Handler(okHandler), ReadHeaderTimeout: time.Second}
+	t.Cleanup(func() { _ = srv.Close() })
+
+	go func() { _ = s.Serve(srv, l) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+
+	get := func(certs ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs, MinVersion: tls.VersionTLS12},
+			// HTTP/2 is only attempted by default without a custom TLS config.
+			ForceAttemptHTTP2: true,
+		}}
+		defer client.CloseIdleConnections()
+
+		return client.Get("https://" + l.Addr().String() + "/metrics")
+	}
+
+	resp, err := get(allowed)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	for name, certs := range map[string][]tls.Certificate{"denied": {denied}, "none": nil} {
+		resp, err := get(certs...)
+		if err == nil {
+			require.NoError(t, resp.Body.Close())
+		}
+
+		require.Error(t, err, name)
+	}
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClientAuth is the policy for client certificates, named after tls.ClientAuthType.
+type ClientAuth string
+
+var clientAuthTypes = map[ClientAuth]tls.ClientAuthType{
+	"":                           tls.NoClientCert,
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+func (a ClientAuth) authType() (tls.ClientAuthType, error) {
+	t, ok := clientAuthTypes[a]
+	if !ok {
+		return 0, fmt.Errorf("unknown client_auth_type %q", a)
+	}
+
+	return t, nil
+}
+
+// TLSVersion is a TLS version, written as TLS10 to TLS13.
+type TLSVersion uint16
+
+var tlsVersions = map[string]TLSVersion{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (v *TLSVersion) UnmarshalYAML(node *yaml.Node) error {
+	version, ok := tlsVersions[node.Value]
+	if !ok {
+		return fmt.Errorf("unknown TLS version %q", node.Value)
+	}
+
+	*v = version
+
+	return nil
+}
+
+// CipherSuite is a TLS 1.0-1.2 cipher suite, written by its Go name such as
+// TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256. Only suites without known security issues are accepted.
+type CipherSuite uint16
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *CipherSuite) UnmarshalYAML(node *yaml.Node) error {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == node.Value {
+			*s = CipherSuite(suite.ID)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown cipher suite %q", node.Value)
+}
+
+// Curve is an elliptic curve for key exchange, written as CurveP256, CurveP384, CurveP521 or X25519.
+type Curve tls.CurveID
+
+var curves = map[string]Curve{
+	"CurveP256": Curve(tls.CurveP256),
+	"CurveP384": Curve(tls.CurveP384),
+	"CurveP521": Curve(tls.CurveP521),
+	"X25519":    Curve(tls.X25519),
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (c *Curve) UnmarshalYAML(node *yaml.Node) error {
+	curve, ok := curves[node.Value]
+	if !ok {
+		return fmt.Errorf("unknown curve %q", node.Value)
+	}
+
+	*c = curve
+
+	return nil
+}